@@ -0,0 +1,162 @@
+package chip8
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Program is the result of statically analyzing a ROM loaded at
+// ProgramStart by following its control flow from the entry point
+// Addresses are absolute, as in Disassemble
+type Program struct {
+	Start uint16 // Load address of the first ROM byte
+	Size  int    // ROM length in bytes
+
+	// Instructions reached from the entry point, by address
+	Instructions map[uint16]Instruction
+
+	// Jump and call targets, each with the sorted addresses of the
+	// instructions referring to it. Targets outside the ROM are included
+	// but not followed
+	XRefs map[uint16][]uint16
+
+	// Sorted targets of CALL instructions
+	Subroutines []uint16
+
+	// Addresses loaded into I by LD I, usually sprite or other data
+	// tables, each with the sorted addresses of the instructions loading it
+	DataRefs map[uint16][]uint16
+
+	// Sorted addresses of Bnnn computed jumps, whose target can't be known
+	// statically. Code only reached through them is reported as data
+	Indeterminate []uint16
+
+	code []bool // Per ROM byte: part of a reached instruction
+}
+
+// Region is a run of ROM bytes that are all code or all data
+type Region struct {
+	Start, End uint16 // End is one past the last byte
+	Code       bool
+}
+
+// Analyze disassembles rom by following jumps, calls and skips from
+// ProgramStart, separating reachable code from data and collecting
+// cross-references. Unknown opcodes end the path that reaches them
+func Analyze(rom []byte) *Program {
+	p := &Program{
+		Start:        ProgramStart,
+		Size:         len(rom),
+		Instructions: make(map[uint16]Instruction),
+		XRefs:        make(map[uint16][]uint16),
+		DataRefs:     make(map[uint16][]uint16),
+		code:         make([]bool, len(rom)),
+	}
+
+	pending := []uint16{ProgramStart}
+	for len(pending) > 0 {
+		addr := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		opcode, ok := p.word(rom, addr)
+		if !ok || p.isDecoded(addr) {
+			continue
+		}
+		inst := DecodeOpcode(opcode)
+		if inst.Op == OpUnknown {
+			continue
+		}
+
+		size := uint16(2)
+		if inst.Op == OpLDILong {
+			long, ok := p.word(rom, addr+2)
+			if !ok {
+				continue
+			}
+			inst.Mnemonic = fmt.Sprintf("LD I, 0x%04X", long)
+			size = 4
+		}
+
+		p.Instructions[addr] = inst
+		for i := range size {
+			p.code[addr-p.Start+i] = true
+		}
+		next := addr + size
+
+		switch inst.Op {
+		case OpJP:
+			p.addXRef(inst.NNN, addr)
+			pending = append(pending, inst.NNN)
+		case OpCALL:
+			p.addXRef(inst.NNN, addr)
+			if !slices.Contains(p.Subroutines, inst.NNN) {
+				p.Subroutines = append(p.Subroutines, inst.NNN)
+			}
+			pending = append(pending, inst.NNN, next)
+		case OpRET:
+		case OpJPV0:
+			p.Indeterminate = append(p.Indeterminate, addr)
+		case OpSEByte, OpSNEByte, OpSEReg, OpSNEReg, OpSKP, OpSKNP:
+			skipped := next + 2
+			if w, ok := p.word(rom, next); ok && w == 0xF000 {
+				skipped += 2
+			}
+			pending = append(pending, next, skipped)
+		case OpLDI:
+			p.DataRefs[inst.NNN] = append(p.DataRefs[inst.NNN], addr)
+			pending = append(pending, next)
+		default:
+			pending = append(pending, next)
+		}
+	}
+
+	slices.Sort(p.Subroutines)
+	slices.Sort(p.Indeterminate)
+	for _, refs := range p.XRefs {
+		slices.Sort(refs)
+	}
+	for _, refs := range p.DataRefs {
+		slices.Sort(refs)
+	}
+	return p
+}
+
+// IsCode reports whether addr holds part of a reachable instruction
+func (p *Program) IsCode(addr uint16) bool {
+	i := int(addr) - int(p.Start)
+	return i >= 0 && i < len(p.code) && p.code[i]
+}
+
+// Regions splits the ROM into alternating runs of code and data
+func (p *Program) Regions() []Region {
+	var regions []Region
+	for i, isCode := range p.code {
+		addr := p.Start + uint16(i)
+		if n := len(regions); n > 0 && regions[n-1].Code == isCode {
+			regions[n-1].End = addr + 1
+			continue
+		}
+		regions = append(regions, Region{Start: addr, End: addr + 1, Code: isCode})
+	}
+	return regions
+}
+
+// word reads the opcode at addr, reporting false if it isn't wholly in rom
+func (p *Program) word(rom []byte, addr uint16) (uint16, bool) {
+	i := int(addr) - int(p.Start)
+	if i < 0 || i+1 >= len(rom) {
+		return 0, false
+	}
+	return uint16(rom[i])<<8 | uint16(rom[i+1]), true
+}
+
+// isDecoded reports whether the instruction at addr has been analyzed
+func (p *Program) isDecoded(addr uint16) bool {
+	_, ok := p.Instructions[addr]
+	return ok
+}
+
+// addXRef records that the instruction at from jumps to or calls target
+func (p *Program) addXRef(target, from uint16) {
+	p.XRefs[target] = append(p.XRefs[target], from)
+}
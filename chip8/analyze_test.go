@@ -0,0 +1,54 @@
+package chip8
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	rom := []byte{
+		0x22, 0x08, // 200: CALL 0x208
+		0xA2, 0x0C, // 202: LD I, 0x20C
+		0xD0, 0x15, // 204: DRW V0, V1, 5
+		0x12, 0x06, // 206: JP 0x206
+		0x60, 0x01, // 208: LD V0, 1
+		0x00, 0xEE, // 20A: RET
+		0xF0, 0x90, 0xF0, 0x90, 0xF0, 0x00, // 20C: sprite table
+	}
+	p := Analyze(rom)
+
+	if got := slices.Sorted(maps.Keys(p.Instructions)); !slices.Equal(got, []uint16{0x200, 0x202, 0x204, 0x206, 0x208, 0x20A}) {
+		t.Errorf("instructions at % X, want 200 through 20A", got)
+	}
+	if !slices.Equal(p.Subroutines, []uint16{0x208}) {
+		t.Errorf("Subroutines = % X, want 208", p.Subroutines)
+	}
+	wantXRefs := map[uint16][]uint16{0x208: {0x200}, 0x206: {0x206}}
+	if !maps.EqualFunc(p.XRefs, wantXRefs, slices.Equal) {
+		t.Errorf("XRefs = %v, want %v", p.XRefs, wantXRefs)
+	}
+	wantData := map[uint16][]uint16{0x20C: {0x202}}
+	if !maps.EqualFunc(p.DataRefs, wantData, slices.Equal) {
+		t.Errorf("DataRefs = %v, want %v", p.DataRefs, wantData)
+	}
+	wantRegions := []Region{{0x200, 0x20C, true}, {0x20C, 0x212, false}}
+	if got := p.Regions(); !slices.Equal(got, wantRegions) {
+		t.Errorf("Regions() = %+v, want %+v", got, wantRegions)
+	}
+	if p.IsCode(0x20C) || !p.IsCode(0x20B) {
+		t.Error("IsCode misreports the code/data boundary at 20C")
+	}
+	if p.Indeterminate != nil {
+		t.Errorf("Indeterminate = % X, want none", p.Indeterminate)
+	}
+
+	// Bnnn's target depends on V0, so analysis stops there
+	p = Analyze([]byte{0x60, 0x02, 0xB2, 0x06, 0x12, 0x04, 0x12, 0x06})
+	if !slices.Equal(p.Indeterminate, []uint16{0x202}) {
+		t.Errorf("Indeterminate = % X, want 202", p.Indeterminate)
+	}
+	if p.IsCode(0x206) {
+		t.Error("code only reached through Bnnn reported as code")
+	}
+}
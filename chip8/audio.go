@@ -0,0 +1,33 @@
+package chip8
+
+// beepAmplitude is the peak sample value of generated tones, a quarter of
+// full scale to leave headroom for mixing
+const beepAmplitude = 8192
+
+// GenerateBeep returns mono 16-bit PCM samples of a square wave at freq Hz
+// lasting durationFrames sound-timer ticks (1/60s each) at sampleRate
+// This only produces samples; playing them is up to the frontend
+// A sampleRate of 0 or less produces no samples and returns nil
+func GenerateBeep(sampleRate int, durationFrames uint8, freq float64) []int16 {
+	if sampleRate <= 0 {
+		return nil
+	}
+
+	samples := make([]int16, int(durationFrames)*sampleRate/TimerHz)
+	if freq <= 0 {
+		return samples
+	}
+
+	for i := range samples {
+		// Position within the current period, in [0, 1)
+		phase := float64(i) * freq / float64(sampleRate)
+		phase -= float64(int(phase))
+
+		if phase < 0.5 {
+			samples[i] = beepAmplitude
+		} else {
+			samples[i] = -beepAmplitude
+		}
+	}
+	return samples
+}
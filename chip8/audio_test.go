@@ -0,0 +1,59 @@
+package chip8
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGenerateBeep(t *testing.T) {
+	samples := GenerateBeep(48000, 3, 1000)
+	if len(samples) != 3*48000/TimerHz {
+		t.Fatalf("len = %d, want %d", len(samples), 3*48000/TimerHz)
+	}
+
+	// 48 samples per period: 24 high, then 24 low
+	for i, s := range samples[:96] {
+		want := int16(beepAmplitude)
+		if i%48 >= 24 {
+			want = -beepAmplitude
+		}
+		if s != want {
+			t.Fatalf("sample %d = %d, want %d", i, s, want)
+		}
+	}
+
+	for _, s := range GenerateBeep(48000, 1, 0) {
+		if s != 0 {
+			t.Fatal("GenerateBeep with freq 0 is not silent")
+		}
+	}
+	for _, rate := range []int{0, -44100} {
+		if got := GenerateBeep(rate, 10, 440); got != nil {
+			t.Errorf("GenerateBeep(%d, ...) = %d samples, want nil", rate, len(got))
+		}
+	}
+}
+
+func TestSoundFrequency(t *testing.T) {
+	tests := []struct {
+		pitch uint8
+		want  float64
+	}{
+		{64, 4000},  // The default pitch
+		{112, 8000}, // One octave up
+		{16, 2000},  // One octave down
+		{0, 4000 * math.Pow(2, -64.0/48)},
+		{255, 4000 * math.Pow(2, 191.0/48)},
+	}
+	for _, tt := range tests {
+		c := loadProgram(t, 0x6000|uint16(tt.pitch), 0xF03A) // LD V0, pitch; PITCH V0
+		step(t, c, 2)
+		if got := c.SoundFrequency(); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("pitch %d: SoundFrequency() = %v, want %v", tt.pitch, got, tt.want)
+		}
+	}
+
+	if got := NewWithSeed(1).SoundFrequency(); got != 4000 {
+		t.Errorf("SoundFrequency() = %v before any Fx3A, want 4000", got)
+	}
+}
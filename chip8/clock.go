@@ -0,0 +1,71 @@
+package chip8
+
+import "time"
+
+// TimerHz is the rate at which the delay and sound timers count down
+const TimerHz = 60
+
+// Clock paces emulation against wall-clock time, running the CPU at a fixed
+// instructions-per-second rate and ticking timers at TimerHz
+// Fractional cycles carry over between steps, as do fractional timer ticks
+// through TickTimersElapsed, so speed stays accurate regardless of how
+// often Step is called
+type Clock struct {
+	hz int
+
+	// Elapsed time scaled by the cycle rate, in nanoseconds; each whole
+	// second's worth is one pending cycle
+	cycleAcc int64
+}
+
+// NewClock returns a Clock that runs the CPU at hz instructions per second
+func NewClock(hz int) *Clock {
+	return &Clock{hz: hz}
+}
+
+// Step runs as many cycles as correspond to elapsed, then advances the
+// timers by elapsed with TickTimersElapsed. It stops at the first cycle
+// that returns an error, without ticking the timers
+func (clk *Clock) Step(c *Chip8, elapsed time.Duration) error {
+	clk.cycleAcc += int64(elapsed) * int64(clk.hz)
+	cycles := clk.cycleAcc / int64(time.Second)
+	clk.cycleAcc -= cycles * int64(time.Second)
+
+	for ; cycles > 0; cycles-- {
+		if err := c.EmulateCycle(); err != nil {
+			return err
+		}
+	}
+	c.TickTimersElapsed(elapsed)
+	return nil
+}
+
+// EnableTimerSkip turns fast-forwarding of delay timer busy-waits on or off
+// When on, EmulateCycle recognizes the loop
+//
+//	loop: LD Vx, DT
+//	      SE Vx, 0
+//	      JP loop
+//
+// and ticks the timers until the delay timer reaches zero before running
+// it, so the loop exits on its next pass. The program ends up in the same
+// state, but in fewer cycles and with extra timer ticks, so it is off by
+// default; it is meant for headless runs
+func (c *Chip8) EnableTimerSkip(enabled bool) {
+	c.timerSkip = enabled
+}
+
+// skipDelayLoop fast-forwards the timers if opcode, at PC, starts a delay
+// timer busy-wait
+func (c *Chip8) skipDelayLoop(opcode uint16) {
+	if c.delayTimer == 0 || opcode&0xF0FF != 0xF007 {
+		return
+	}
+	x := opcode & 0x0F00
+	if c.PC > 0x0FFF || c.readWord(c.PC+2) != 0x3000|x || c.readWord(c.PC+4) != 0x1000|c.PC {
+		return
+	}
+	for c.delayTimer > 0 {
+		c.TickTimers()
+	}
+}
@@ -0,0 +1,99 @@
+package chip8
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerSkipKeepsFinalState(t *testing.T) {
+	program := []uint16{
+		0x6030, 0xF015, // LD V0, 48; LD DT, V0
+		0x6114, 0xF118, // LD V1, 20; LD ST, V1
+		0xF207, 0x3200, 0x1208, // loop: LD V2, DT; SE V2, 0; JP loop
+		0x6305, 0xF329, 0xD015, // LD V3, 5; LD F, V3; DRW V0, V1, 5
+		0x1214,
+	}
+	run := func(skip bool) (*Chip8, uint64) {
+		c := loadProgram(t, program...)
+		c.EnableTimerSkip(skip)
+		for i := 1; !c.Halted(); i++ {
+			if i > 10000 {
+				t.Fatalf("skip=%v: program didn't halt", skip)
+			}
+			step(t, c, 1)
+			if i%10 == 0 {
+				c.TickTimers()
+			}
+		}
+		return c, c.CycleCount()
+	}
+
+	slow, slowCycles := run(false)
+	fast, fastCycles := run(true)
+	if fastCycles >= slowCycles {
+		t.Errorf("timer skip ran %d cycles, want fewer than %d", fastCycles, slowCycles)
+	}
+	if fast.V != slow.V || fast.I != slow.I || fast.PC != slow.PC {
+		t.Errorf("registers differ: V = % X I = %03X PC = %03X, want % X %03X %03X",
+			fast.V, fast.I, fast.PC, slow.V, slow.I, slow.PC)
+	}
+	if fast.DelayTimer() != slow.DelayTimer() || fast.SoundTimer() != slow.SoundTimer() {
+		t.Errorf("timers differ: DT %d ST %d, want %d %d",
+			fast.DelayTimer(), fast.SoundTimer(), slow.DelayTimer(), slow.SoundTimer())
+	}
+	if fast.DisplayHash() != slow.DisplayHash() {
+		t.Error("display differs with timer skip")
+	}
+}
+
+func TestTickTimersElapsed(t *testing.T) {
+	c := loadProgram(t, 0x601E, 0xF015) // LD V0, 30; LD DT, V0
+	step(t, c, 2)
+
+	var total time.Duration
+	durations := []time.Duration{7 * time.Millisecond, 13 * time.Millisecond, 20*time.Millisecond + 500*time.Microsecond}
+	for i := 0; total < time.Second; i++ {
+		d := durations[i%len(durations)]
+		c.TickTimersElapsed(d)
+		total += d
+
+		want := max(30-int(total*TimerHz/time.Second), 0)
+		if got := int(c.DelayTimer()); got != want {
+			t.Fatalf("after %v: DelayTimer() = %d, want %d", total, got, want)
+		}
+		if total < 500*time.Millisecond && c.DelayTimer() == 0 {
+			t.Fatalf("delay timer reached zero early, after %v", total)
+		}
+	}
+	if c.DelayTimer() != 0 {
+		t.Errorf("DelayTimer() = %d after %v, want 0", c.DelayTimer(), total)
+	}
+}
+
+func TestClockStep(t *testing.T) {
+	c := loadProgram(t, 0x603C, 0xF015, 0x7101, 0x1204) // DT = 60; loop: ADD V1, 1
+	clk := NewClock(600)
+
+	// 25ms steps: 15 cycles and 1.5 timer ticks each
+	for range 40 {
+		if err := clk.Step(c, 25*time.Millisecond); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+	}
+	if got := c.CycleCount(); got != 600 {
+		t.Errorf("CycleCount() = %d after 1s at 600Hz, want 600", got)
+	}
+	if got := c.DelayTimer(); got != 0 {
+		t.Errorf("DelayTimer() = %d after 1s, want 0", got)
+	}
+
+	// The timer accumulator is shared with TickTimersElapsed
+	c.delayTimer = 10
+	if err := clk.Step(c, 8*time.Millisecond); err != nil { // Half a tick
+		t.Fatalf("Step: %v", err)
+	}
+	c.TickTimersElapsed(9 * time.Millisecond)
+	if got := c.DelayTimer(); got != 9 {
+		t.Errorf("DelayTimer() = %d, want 9 from the two half ticks", got)
+	}
+}
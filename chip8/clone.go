@@ -0,0 +1,89 @@
+package chip8
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+)
+
+// Clone returns an independent deep copy of the emulator: memory and its
+// banks, machine state, configuration, breakpoints, watches, profile
+// counts, rewind history and OverrideOpcode handlers are all copied, and
+// the clone's Cxkk random source continues from the same point in the
+// sequence. Callbacks, the log writer, a custom renderer and any recording
+// in progress are not copied; the clone draws into its own copy of the
+// display buffer. A source set with SetRandSource can't be copied and is
+// shared with the clone
+func (c *Chip8) Clone() *Chip8 {
+	clone := *c
+
+	clone.memory = slices.Clone(c.memory)
+	if c.banks != nil {
+		clone.banks = make([][]uint8, len(c.banks))
+		for i, bank := range c.banks {
+			clone.banks[i] = slices.Clone(bank)
+		}
+		clone.memory = clone.banks[c.bank]
+	}
+	clone.font = slices.Clone(c.font)
+	clone.keyQueue = slices.Clone(c.keyQueue)
+	clone.breakpoints = maps.Clone(c.breakpoints)
+	clone.opcodeBreakpoints = slices.Clone(c.opcodeBreakpoints)
+	clone.memoryWatches = slices.Clone(c.memoryWatches)
+	clone.profile = maps.Clone(c.profile)
+	if c.rewind != nil {
+		clone.rewind = &rewindBuffer{
+			states: slices.Clone(c.rewind.states), // Entries are never modified
+			next:   c.rewind.next,
+			count:  c.rewind.count,
+		}
+	}
+	if c.seeded != nil {
+		clone.seeded = c.seeded.clone()
+		clone.rng = rand.New(clone.seeded)
+	}
+
+	clone.renderer = displayRenderer{&clone}
+	clone.soundHandler = nil
+	clone.drawCallback = nil
+	clone.collisionCallback = nil
+	clone.traceFunc = nil
+	clone.unknownHandler = nil
+	clone.watchCallback = nil
+	clone.logWriter = nil
+	clone.recording = nil
+
+	return &clone
+}
+
+// seededSource is the default random source, a SplitMix64 generator
+// Its whole state is one word, so a copy continues the same sequence
+type seededSource struct {
+	state uint64
+}
+
+func newSeededSource(seed int64) *seededSource {
+	return &seededSource{state: uint64(seed)}
+}
+
+func (s *seededSource) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ z>>30) * 0xBF58476D1CE4E5B9
+	z = (z ^ z>>27) * 0x94D049BB133111EB
+	return z ^ z>>31
+}
+
+func (s *seededSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *seededSource) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// clone returns a source that will produce the same values as s
+func (s *seededSource) clone() *seededSource {
+	clone := *s
+	return &clone
+}
@@ -0,0 +1,45 @@
+package chip8
+
+import "testing"
+
+func TestCloneIsIndependent(t *testing.T) {
+	c := loadProgram(t, 0xC0FF, 0xC1FF, 0xC2FF, 0xA300, 0xF255) // RND V0..V2; LD I; LD [I], V2
+	if err := c.SetMemoryBanks(2); err != nil {
+		t.Fatalf("SetMemoryBanks: %v", err)
+	}
+	step(t, c, 1)
+
+	clone := c.Clone()
+	step(t, c, 4)
+	step(t, clone, 4)
+	if c.V != clone.V {
+		t.Errorf("clone V = % X, want % X from the same random sequence", clone.V, c.V)
+	}
+
+	clone.V[0]++
+	clone.memory[0x300]++
+	if err := clone.SwitchBank(1); err != nil {
+		t.Fatalf("SwitchBank: %v", err)
+	}
+	clone.memory[0x300] = 0xEE
+	if c.V[0] == clone.V[0] || c.memory[0x300] != c.V[0] {
+		t.Error("changing the clone's registers or memory changed the original")
+	}
+	if c.ActiveBank() != 0 || c.banks[1][0x300] == 0xEE {
+		t.Error("switching or writing the clone's bank changed the original")
+	}
+}
+
+func TestCloneContinuesRandomSequence(t *testing.T) {
+	c := NewWithSeed(7)
+	for range 100_000 {
+		c.rng.Intn(256)
+	}
+
+	clone := c.Clone()
+	for i := range 16 {
+		if got, want := clone.rng.Intn(256), c.rng.Intn(256); got != want {
+			t.Fatalf("draw %d: clone got %d, want %d", i, got, want)
+		}
+	}
+}
@@ -1,23 +1,57 @@
 package chip8
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"math/rand"
+	"time"
 )
 
 const (
-	MemorySize    = 4096
+	MemorySize    = 4096  // Default memory size
+	XOMemorySize  = 65536 // XO-CHIP memory size, the largest supported
 	RegisterCount = 16
 	StackSize     = 16
 	ScreenWidth   = 64
 	ScreenHeight  = 32
+	HiResWidth    = 128 // SUPER-CHIP high-resolution width
+	HiResHeight   = 64  // SUPER-CHIP high-resolution height
 	FontsetSize   = 80
 )
 
+// Default memory map: the small font from FontStart, the large font from
+// BigFontStart, and programs from ProgramStart. Everything below
+// ProgramStart is reserved for the interpreter
+const (
+	FontStart    = 0x000
+	ProgramStart = 0x200
+)
+
+// defaultPitch is the XO-CHIP pitch register's initial value
+const defaultPitch = 64
+
 // Chip8 represents the entire emulator state
 type Chip8 struct {
 	// Memory
-	memory [MemorySize]uint8
+	memory []uint8 // MemorySize bytes unless created with NewWithMemory
+
+	// Every memory bank, one of which is memory, when SetMemoryBanks
+	// enabled banking, and the index of the active one
+	banks [][]uint8
+	bank  int
+
+	// Where ROMs are loaded and execution starts, and the loaded ROM's length
+	startAddr uint16
+	romSize   int
+
+	// Problems noticed in the last ROM loaded that did not stop it loading
+	loadWarnings []string
+
+	// Small font used by Fx29 and where it is loaded
+	font     []uint8
+	fontAddr uint16
 
 	// Registers
 	V  [RegisterCount]uint8 // V0-VF (VF is flag register)
@@ -32,14 +66,200 @@ type Chip8 struct {
 	delayTimer uint8
 	soundTimer uint8
 
-	// Display (64x32 pixels, 1 bit per pixel)
-	display [ScreenWidth * ScreenHeight]uint8
+	// XO-CHIP audio: a 128-sample 1-bit pattern played while the sound
+	// timer is active, at a rate set by the pitch register
+	audioPattern [16]uint8
+	pitch        uint8
+
+	// SUPER-CHIP RPL user flags written by Fx75 and read by Fx85
+	// Like the HP48's, they survive Reset
+	rplFlags [8]uint8
+
+	// Receives drawing operations; displayRenderer draws into display
+	renderer Renderer
+
+	// Display (64x32 pixels in lores, 128x64 in hires)
+	// Pixels are stored row-major using the active resolution's width
+	// Each pixel holds one bit per XO-CHIP plane; plane 1 is bit 0
+	display [HiResWidth * HiResHeight]uint8
+	hires   bool
+	planes  uint8 // Planes affected by draw, clear and scroll (Fn01)
+
+	// Bounding box of pixels changed since the last DrawFlag or ClearDirty,
+	// as inclusive minimum and exclusive maximum coordinates
+	dirty                bool
+	dirtyMinX, dirtyMinY int
+	dirtyMaxX, dirtyMaxY int
+
+	// Phosphor-decay brightness per display pixel, faded by TickTimers
+	intensity [HiResWidth * HiResHeight]uint8
+	fadeRate  uint8
 
 	// Keyboard state (16 keys)
-	keys [16]bool
+	keys   [16]bool
+	keyMap KeyMap // Host rune to key translation used by PressRune
+
+	// Buffered input: events queued by QueueKey, applied one per cycle
+	bufferedInput bool
+	keyQueue      []keyEvent
+
+	// Keys seen down while Fx0A waits for a release
+	waitingForKey bool
+	waitKeysDown  [16]bool
+
+	// Keys whose press completed an Fx0A wait; the program ignores them
+	// until they are released
+	consumedKeys [16]bool
+
+	// Flag to indicate if display needs redrawing, and how many times the
+	// display has changed
+	drawFlag  bool
+	drawCount uint64
+
+	// Random source used by Cxkk, and the seeded source behind it unless
+	// replaced with SetRandSource
+	rng    *rand.Rand
+	seeded *seededSource
+
+	// Set when the last instruction was a 1nnn jumping to itself, or a
+	// RET halted by RETHalt
+	halted bool
+	haltPC uint16
+
+	// Executed instruction count, total and per leading opcode nibble
+	cycles      uint64
+	nibbleStats [16]uint64
+
+	// Address and opcode of the last instruction executed
+	lastPC     uint16
+	lastOpcode uint16
+
+	// Executions per instruction address, when profiling is enabled
+	profile map[uint16]uint64
+
+	// Cost of each leading opcode nibble, and of the last instruction run
+	cycleCosts [16]int
+	lastCost   int
+
+	// Most instructions one Run call may execute; 0 for no limit
+	cycleBudget uint64
+
+	// Set by Pause; execution and timers are frozen until Resume
+	paused bool
 
-	// Flag to indicate if display needs redrawing
-	drawFlag bool
+	// Set by EnableTimerSkip to fast-forward delay timer busy-waits
+	timerSkip bool
+
+	// Set by Dxyn under the DisplayWait quirk until the next timer tick
+	waitingForVBlank bool
+
+	// Time passed to TickTimersElapsed scaled by TimerHz, in nanoseconds,
+	// not yet spent on a whole tick
+	timerAcc int64
+
+	// Set by NewHeadless; output callbacks and renderers can't be installed
+	headless bool
+
+	// Called when the sound timer starts or stops the beep
+	soundHandler func(playing bool)
+
+	// States captured before each instruction, when rewinding is enabled
+	rewind *rewindBuffer
+
+	// Called with a snapshot of the display whenever the draw flag is set
+	drawCallback func(display [ScreenWidth * ScreenHeight]uint8)
+
+	// Called once for each sprite draw in which a pixel collided
+	collisionCallback func(x, y uint8)
+
+	// Set while RunFrame defers draw and sound notifications
+	inFrame    bool
+	frameDrawn bool
+
+	// Called with each fetched opcode before it executes
+	traceFunc func(pc uint16, opcode uint16)
+
+	// Key recording in progress and recording being replayed, with the
+	// cycle count each started at and the next event to replay
+	recording     *Recording
+	recordStart   uint64
+	playback      *Recording
+	playbackStart uint64
+	playbackNext  int
+
+	// Receives one line per executed instruction; see SetLogWriter
+	logWriter io.Writer
+
+	// Handlers set with OverrideOpcode, by leading opcode nibble
+	opcodeHandlers [16]func(c *Chip8, opcode uint16) error
+
+	// Given the first chance at opcodes that fail to decode
+	unknownHandler func(c *Chip8, opcode uint16) (handled bool, advance bool)
+
+	// Watched memory addresses (sorted) and registers (bit n for Vn), and
+	// the callback told when an instruction changes one
+	memoryWatches   []uint16
+	registerWatches uint16
+	watchCallback   func(kind string, index int, old, new uint8)
+
+	// Breakpoint addresses and opcode patterns, and the breakpoint the
+	// last cycle stopped at so the next cycle executes it rather than
+	// breaking again
+	breakpoints       map[uint16]bool
+	opcodeBreakpoints []opcodeBreakpoint
+	resuming          bool
+	resumeAt          uint16
+
+	// Quirks selects implementation-specific opcode behavior
+	Quirks Quirks
+
+	// MinSoundFrames is the shortest beep Fx18 will start, in 60Hz frames
+	// Shorter nonzero values are raised to it to avoid click artifacts
+	// Zero (the default) leaves the sound timer exactly as programmed
+	MinSoundFrames uint8
+
+	// SkipUnknownOpcodes advances the PC past opcodes that fail to decode,
+	// so loops that ignore EmulateCycle errors keep running
+	SkipUnknownOpcodes bool
+
+	// RETOnEmptyStack selects what a RET with nothing on the stack does
+	RETOnEmptyStack RETPolicy
+
+	// ProtectLowMemory makes instructions that write memory (Fx33, Fx55)
+	// fail with a *ProtectedWriteError instead of writing below
+	// ProgramStart, to catch ROMs that corrupt the interpreter area
+	// WriteMemory and the loaders are not affected
+	ProtectLowMemory bool
+}
+
+// RETPolicy selects how a RET with an empty stack is handled
+type RETPolicy int
+
+const (
+	RETError RETPolicy = iota // EmulateCycle returns ErrStackUnderflow (the default)
+	RETHalt                   // The program halts at the RET; see Halted
+	RETNop                    // The RET is skipped like a no-op
+)
+
+// Errors returned by EmulateCycle when a CALL or RET would leave the stack
+// bounds. The offending instruction is not executed
+var (
+	ErrStackOverflow  = errors.New("stack overflow")
+	ErrStackUnderflow = errors.New("stack underflow")
+)
+
+// ErrPCOutOfBounds is returned by EmulateCycle when the program counter has
+// run past the end of memory and the WrapPC quirk is off
+var ErrPCOutOfBounds = errors.New("program counter out of bounds")
+
+// UnknownOpcodeError is returned when an opcode cannot be decoded
+type UnknownOpcodeError struct {
+	Opcode uint16 // The offending opcode
+	PC     uint16 // Address the opcode was fetched from
+}
+
+func (e *UnknownOpcodeError) Error() string {
+	return fmt.Sprintf("unknown opcode 0x%04X at 0x%03X", e.Opcode, e.PC)
 }
 
 // Font sprites (0-F), stored in memory at 0x000-0x050
@@ -64,325 +284,1142 @@ var fontset = [FontsetSize]uint8{
 }
 
 // New creates and initializes a new Chip8 emulator
+// The random source used by Cxkk is seeded from the current time
 func New() *Chip8 {
+	return NewWithSeed(time.Now().UnixNano())
+}
+
+// NewWithSeed creates a new Chip8 emulator whose Cxkk random source is
+// seeded with seed, making execution fully reproducible
+func NewWithSeed(seed int64) *Chip8 {
+	return newChip8(seed, MemorySize)
+}
+
+// NewWithMemory creates a new Chip8 emulator with size bytes of memory,
+// e.g. XOMemorySize for XO-CHIP programs
+func NewWithMemory(size int) (*Chip8, error) {
+	if size <= ProgramStart || size > XOMemorySize {
+		return nil, fmt.Errorf("invalid memory size: %d bytes (must be over %d and at most %d)", size, ProgramStart, XOMemorySize)
+	}
+	return newChip8(time.Now().UnixNano(), size), nil
+}
+
+// NewHeadless creates a Chip8 emulator for non-interactive use such as
+// tests and servers. It never calls out to presentation code:
+// SetDrawCallback, SetSoundHandler and SetRenderer are ignored, so drawing
+// only updates the internal display buffer, which DisplayHash and the
+// other display accessors still read. The package itself never writes to
+// stdout, in headless mode or otherwise
+func NewHeadless() *Chip8 {
+	c := New()
+	c.headless = true
+	return c
+}
+
+// newChip8 creates an emulator with the given random seed and memory size
+func newChip8(seed int64, memorySize int) *Chip8 {
 	c := &Chip8{
-		PC: 0x200, // Programs start at 0x200
+		memory:             make([]uint8, memorySize),
+		PC:                 ProgramStart,
+		startAddr:          ProgramStart,
+		fontAddr:           FontStart,
+		planes:             1,
+		pitch:              defaultPitch,
+		font:               fontset[:],
+		keyMap:             DefaultKeyMap(),
+		Quirks:             defaultQuirks(),
+		SkipUnknownOpcodes: true,
 	}
 
-	// Load fontset into memory (0x000 to 0x050)
-	copy(c.memory[:FontsetSize], fontset[:])
+	c.renderer = displayRenderer{c}
+	c.seeded = newSeededSource(seed)
+	c.rng = rand.New(c.seeded)
+
+	// Load fontsets into memory (0x000 to 0x0F0)
+	c.loadFonts()
+
+	c.SetCycleCosts(DefaultCycleCosts())
 
 	return c
 }
 
-// LoadROM loads a ROM into memory starting at 0x200
+// SetRandSource replaces the random source used by Cxkk
+func (c *Chip8) SetRandSource(src rand.Source) {
+	c.rng = rand.New(src)
+	c.seeded = nil
+}
+
+// Reset restarts the machine without reloading the ROM: registers, stack,
+// timers, display and keys are cleared, the fontsets are restored, PC
+// returns to the start address and memory bank 0 is selected. The rest of
+// memory and the RPL user flags are left intact
+func (c *Chip8) Reset() {
+	c.ResetWith(ResetOptions{})
+}
+
+// ResetOptions adjusts what ResetWith clears
+type ResetOptions struct {
+	// KeepDisplay leaves the display, its resolution and the selected
+	// XO-CHIP planes as they were, e.g. to keep showing the final frame of
+	// a run. The draw flag is then left untouched rather than set, and no
+	// draw callback is made, since the picture did not change
+	KeepDisplay bool
+}
+
+// ResetWith resets the machine like Reset, with the differences selected
+// by opts
+func (c *Chip8) ResetWith(opts ResetOptions) {
+	c.selectBank(0)
+
+	c.V = [RegisterCount]uint8{}
+	c.I = 0
+	c.PC = c.startAddr
+	c.stack = [StackSize]uint16{}
+	c.SP = 0
+
+	c.delayTimer = 0
+	c.setSoundTimer(0)
+	c.audioPattern = [16]uint8{}
+	c.pitch = defaultPitch
+
+	if !opts.KeepDisplay {
+		c.hires = false
+		c.planes = 1
+		c.renderer.Resize(ScreenWidth, ScreenHeight)
+		c.markAllDirty()
+		c.markDrawn()
+	}
+
+	c.keys = [16]bool{}
+	c.keyQueue = nil
+	c.waitingForKey = false
+	c.consumedKeys = [16]bool{}
+	c.waitingForVBlank = false
+	c.timerAcc = 0
+	c.resuming = false
+
+	c.cycles = 0
+	c.lastPC, c.lastOpcode = 0, 0
+	c.nibbleStats = [16]uint64{}
+	if c.profile != nil {
+		c.profile = make(map[uint16]uint64)
+	}
+	c.halted = false
+
+	// Recording and playback continue, counted from the reset
+	c.recordStart = 0
+	c.playbackStart = 0
+
+	c.loadFonts()
+}
+
+// ResetFull resets the machine and zeroes all memory, in every bank, so a
+// ROM must be loaded again before running
+func (c *Chip8) ResetFull() {
+	clear(c.memory)
+	for _, bank := range c.banks {
+		clear(bank)
+	}
+	c.romSize = 0
+	c.Reset()
+}
+
+// SetStartAddress changes where ROMs are loaded and execution begins, e.g.
+// 0x600 for ETI-660 programs. PC is moved there immediately, and Reset
+// returns to it. Call it before LoadROM
+func (c *Chip8) SetStartAddress(addr uint16) error {
+	if int(addr)+2 > len(c.memory) {
+		return fmt.Errorf("start address out of range: 0x%04X (memory size %d)", addr, len(c.memory))
+	}
+
+	c.startAddr = addr
+	c.PC = addr
+	return nil
+}
+
+// LoadROM loads a ROM into memory at the start address (0x200 by default)
+// Suspicious but loadable ROMs, e.g. of odd length, are reported by
+// LastLoadWarnings
 func (c *Chip8) LoadROM(rom []byte) error {
-	if len(rom) > MemorySize-0x200 {
-		return fmt.Errorf("ROM too large: %d bytes (max %d)", len(rom), MemorySize-0x200)
+	if maxSize := len(c.memory) - int(c.startAddr); len(rom) > maxSize {
+		return fmt.Errorf("ROM too large: %d bytes (max %d)", len(rom), maxSize)
 	}
 
-	copy(c.memory[0x200:], rom)
+	copy(c.memory[c.startAddr:], rom)
+	c.romSize = len(rom)
+
+	c.loadWarnings = nil
+	if len(rom)%2 != 0 {
+		c.loadWarnings = append(c.loadWarnings,
+			fmt.Sprintf("ROM has odd length: %d bytes, possibly truncated", len(rom)))
+	}
 	return nil
 }
 
-// EmulateCycle executes one CPU cycle
-func (c *Chip8) EmulateCycle() {
+// EmulateCycle executes one CPU instruction. Timers are not updated; see
+// TickTimers. It returns an *UnknownOpcodeError if the fetched opcode could
+// not be decoded, a *MemoryAccessError, *ProtectedWriteError or one of the
+// Err* values if execution cannot continue safely, or ErrBreakpoint
+// without executing anything if PC is at a breakpoint or the opcode
+// matches an opcode breakpoint
+// Calling EmulateCycle again after a breakpoint executes the instruction there
+// While paused, or waiting for the next timer tick under the DisplayWait
+// quirk, it does nothing and returns nil
+func (c *Chip8) EmulateCycle() error {
+	if c.paused || c.waitingForVBlank {
+		return nil
+	}
+
 	// Fetch opcode (2 bytes, big-endian)
-	opcode := uint16(c.memory[c.PC])<<8 | uint16(c.memory[c.PC+1])
+	if c.Quirks.WrapPC {
+		c.PC = uint16(int(c.PC) % len(c.memory))
+	} else if int(c.PC)+1 >= len(c.memory) {
+		return ErrPCOutOfBounds
+	}
+	opcode := c.readWord(c.PC)
+
+	if (c.breakpoints[c.PC] || c.opcodeBreakpointHit(opcode)) && !(c.resuming && c.resumeAt == c.PC) {
+		c.resuming = true
+		c.resumeAt = c.PC
+		return ErrBreakpoint
+	}
+	c.resuming = false
+
+	if c.playback != nil {
+		c.applyPlayback()
+	}
+
+	if len(c.keyQueue) > 0 {
+		event := c.keyQueue[0]
+		c.keyQueue = c.keyQueue[1:]
+		c.SetKey(event.key, event.pressed)
+	}
+
+	if c.traceFunc != nil {
+		c.traceFunc(c.PC, opcode)
+	}
+
+	if c.rewind != nil {
+		c.rewind.push(c.captureRewind())
+	}
+
+	c.cycles++
+	c.lastPC, c.lastOpcode = c.PC, opcode
+	c.nibbleStats[opcode>>12]++
+	if c.profile != nil {
+		c.profile[c.PC]++
+	}
+	c.lastCost = c.cycleCosts[opcode>>12]
+	c.halted = false
+
+	if c.timerSkip {
+		c.skipDelayLoop(opcode)
+	}
 
 	// Decode and execute
-	c.executeOpcode(opcode)
+	if len(c.memoryWatches) > 0 || c.registerWatches != 0 {
+		return c.executeWatched(opcode)
+	}
+	return c.execute(opcode)
+}
 
-	// Update timers
+// execute runs executeOpcode, logging the instruction if a log writer is set
+func (c *Chip8) execute(opcode uint16) error {
+	if c.logWriter != nil {
+		return c.executeLogged(opcode)
+	}
+	return c.executeOpcode(opcode)
+}
+
+// TickTimers counts the delay and sound timers down by one
+// CHIP-8 timers run at 60Hz independently of the CPU, so the host should
+// call this exactly 60 times per second. Timers do not run while paused
+func (c *Chip8) TickTimers() {
+	if c.paused {
+		return
+	}
+
+	c.waitingForVBlank = false
 	if c.delayTimer > 0 {
 		c.delayTimer--
 	}
 	if c.soundTimer > 0 {
-		c.soundTimer--
-	}
-}
-
-// executeOpcode decodes and executes a single opcode
-func (c *Chip8) executeOpcode(opcode uint16) {
-	// Extract common opcode parts
-	// opcode format: 0xABCD
-	nnn := opcode & 0x0FFF             // lowest 12 bits
-	n := uint8(opcode & 0x000F)        // lowest 4 bits
-	x := uint8((opcode & 0x0F00) >> 8) // lower 4 bits of high byte
-	y := uint8((opcode & 0x00F0) >> 4) // upper 4 bits of low byte
-	kk := uint8(opcode & 0x00FF)       // lowest 8 bits
-
-	// Decode based on first nibble
-	switch opcode & 0xF000 {
-	case 0x0000:
-		switch opcode {
-		case 0x00E0: // 00E0 - CLS: Clear display
-			for i := range c.display {
-				c.display[i] = 0
-			}
-			c.drawFlag = true
-			c.PC += 2
+		c.setSoundTimer(c.soundTimer - 1)
+	}
 
-		case 0x00EE: // 00EE - RET: Return from subroutine
-			c.SP--
-			c.PC = c.stack[c.SP]
-			c.PC += 2
+	c.fadePixels()
+}
 
-		default:
-			fmt.Printf("Unknown opcode: 0x%X\n", opcode)
-			c.PC += 2
+// TickTimersElapsed ticks the timers once for every 1/60th of a second in
+// elapsed, carrying the remainder over to the next call, so timers keep
+// time for hosts whose frame rate isn't 60Hz. Time does not accumulate
+// while paused
+func (c *Chip8) TickTimersElapsed(elapsed time.Duration) {
+	if c.paused {
+		return
+	}
+
+	c.timerAcc += int64(elapsed) * TimerHz
+	ticks := c.timerAcc / int64(time.Second)
+	c.timerAcc -= ticks * int64(time.Second)
+
+	for ; ticks > 0; ticks-- {
+		c.TickTimers()
+	}
+}
+
+// StepFrame runs one 60Hz frame: cyclesPerFrame CPU cycles followed by a
+// single timer tick. It is equivalent to RunFrame
+func (c *Chip8) StepFrame(cyclesPerFrame int) error {
+	return c.RunFrame(cyclesPerFrame)
+}
+
+// executeOpcode executes a single opcode, handing it to the handler
+// overriding its class if there is one
+func (c *Chip8) executeOpcode(opcode uint16) error {
+	if handler := c.opcodeHandlers[opcode>>12]; handler != nil {
+		return handler(c, opcode)
+	}
+	return c.executeBuiltin(opcode)
+}
+
+// executeBuiltin decodes and executes a single opcode with the built-in
+// implementation of its class
+//
+// The flag-setting arithmetic instructions (8xy4, 8xy5, 8xy6, 8xy7, 8xyE)
+// read their operands, then set VF from them, then store the result in
+// Vx. When x is F the result therefore replaces the flag, and when y is F
+// the old VF is used as the operand
+func (c *Chip8) executeBuiltin(opcode uint16) error {
+	inst := decode(opcode)
+	nnn, n, x, y, kk := inst.NNN, inst.N, inst.X, inst.Y, inst.KK
+
+	switch inst.Op {
+	case OpSCD: // 00Cn - SCD n: Scroll display down n pixels (SUPER-CHIP)
+		c.scroll(0, int(n))
+		c.PC += 2
+
+	case OpCLS: // 00E0 - CLS: Clear display (selected planes only)
+		c.clearScreen()
+		c.PC += 2
+
+	case OpSCR: // 00FB - SCR: Scroll display right 4 pixels (SUPER-CHIP)
+		c.scroll(4, 0)
+		c.PC += 2
+
+	case OpSCL: // 00FC - SCL: Scroll display left 4 pixels (SUPER-CHIP)
+		c.scroll(-4, 0)
+		c.PC += 2
+
+	case OpLOW: // 00FE - LOW: Disable high-resolution mode (SUPER-CHIP)
+		c.setHiRes(false)
+		c.PC += 2
+
+	case OpHIGH: // 00FF - HIGH: Enable high-resolution mode (SUPER-CHIP)
+		c.setHiRes(true)
+		c.PC += 2
+
+	case OpRET: // 00EE - RET: Return from subroutine
+		if c.SP == 0 {
+			switch c.RETOnEmptyStack {
+			case RETHalt:
+				c.halted = true
+				c.haltPC = c.PC
+				return nil
+			case RETNop:
+				c.PC += 2
+				return nil
+			}
+			return ErrStackUnderflow
 		}
+		c.SP--
+		c.PC = c.stack[c.SP]
+		c.PC += 2
 
-	case 0x1000: // 1nnn - JP addr: Jump to address nnn
+	case OpJP: // 1nnn - JP addr: Jump to address nnn
+		if nnn == c.PC {
+			c.halted = true
+			c.haltPC = nnn
+		}
 		c.PC = nnn
 
-	case 0x2000: // 2nnn - CALL addr: Call subroutine at nnn
+	case OpCALL: // 2nnn - CALL addr: Call subroutine at nnn
+		if int(c.SP) >= StackSize {
+			return ErrStackOverflow
+		}
 		c.stack[c.SP] = c.PC
 		c.SP++
 		c.PC = nnn
 
-	case 0x3000: // 3xkk - SE Vx, byte: Skip next instruction if Vx == kk
+	case OpSEByte: // 3xkk - SE Vx, byte: Skip next instruction if Vx == kk
 		if c.V[x] == kk {
-			c.PC += 4
+			c.skipNext()
 		} else {
 			c.PC += 2
 		}
 
-	case 0x4000: // 4xkk - SNE Vx, byte: Skip next instruction if Vx != kk
+	case OpSNEByte: // 4xkk - SNE Vx, byte: Skip next instruction if Vx != kk
 		if c.V[x] != kk {
-			c.PC += 4
+			c.skipNext()
 		} else {
 			c.PC += 2
 		}
 
-	case 0x5000: // 5xy0 - SE Vx, Vy: Skip next instruction if Vx == Vy
+	case OpSEReg: // 5xy0 - SE Vx, Vy: Skip next instruction if Vx == Vy
 		if c.V[x] == c.V[y] {
-			c.PC += 4
+			c.skipNext()
 		} else {
 			c.PC += 2
 		}
 
-	case 0x6000: // 6xkk - LD Vx, byte: Set Vx = kk
+	case OpLDByte: // 6xkk - LD Vx, byte: Set Vx = kk
 		c.V[x] = kk
 		c.PC += 2
 
-	case 0x7000: // 7xkk - ADD Vx, byte: Set Vx = Vx + kk
+	case OpADDByte: // 7xkk - ADD Vx, byte: Set Vx = Vx + kk
 		c.V[x] += kk
 		c.PC += 2
 
-	case 0x8000:
-		switch opcode & 0x000F {
-		case 0x0000: // 8xy0 - LD Vx, Vy: Set Vx = Vy
-			c.V[x] = c.V[y]
-			c.PC += 2
-
-		case 0x0001: // 8xy1 - OR Vx, Vy: Set Vx = Vx OR Vy
-			c.V[x] |= c.V[y]
-			c.PC += 2
-
-		case 0x0002: // 8xy2 - AND Vx, Vy: Set Vx = Vx AND Vy
-			c.V[x] &= c.V[y]
-			c.PC += 2
+	case OpLDReg: // 8xy0 - LD Vx, Vy: Set Vx = Vy
+		c.V[x] = c.V[y]
+		c.PC += 2
 
-		case 0x0003: // 8xy3 - XOR Vx, Vy: Set Vx = Vx XOR Vy
-			c.V[x] ^= c.V[y]
-			c.PC += 2
+	case OpOR: // 8xy1 - OR Vx, Vy: Set Vx = Vx OR Vy
+		c.V[x] |= c.V[y]
+		if c.Quirks.LogicResetsVF {
+			c.V[0xF] = 0
+		}
+		c.PC += 2
 
-		case 0x0004: // 8xy4 - ADD Vx, Vy: Set Vx = Vx + Vy, set VF = carry
-			sum := uint16(c.V[x]) + uint16(c.V[y])
+	case OpAND: // 8xy2 - AND Vx, Vy: Set Vx = Vx AND Vy
+		c.V[x] &= c.V[y]
+		if c.Quirks.LogicResetsVF {
 			c.V[0xF] = 0
-			if sum > 0xFF {
-				c.V[0xF] = 1
-			}
-			c.V[x] = uint8(sum)
-			c.PC += 2
+		}
+		c.PC += 2
 
-		case 0x0005: // 8xy5 - SUB Vx, Vy: Set Vx = Vx - Vy, set VF = NOT borrow
+	case OpXOR: // 8xy3 - XOR Vx, Vy: Set Vx = Vx XOR Vy
+		c.V[x] ^= c.V[y]
+		if c.Quirks.LogicResetsVF {
 			c.V[0xF] = 0
-			if c.V[x] > c.V[y] {
-				c.V[0xF] = 1
-			}
-			c.V[x] -= c.V[y]
-			c.PC += 2
+		}
+		c.PC += 2
 
-		case 0x0006: // 8xy6 - SHR Vx: Set Vx = Vx SHR 1
-			c.V[0xF] = c.V[x] & 0x1
-			c.V[x] >>= 1
-			c.PC += 2
+	case OpADDReg: // 8xy4 - ADD Vx, Vy: Set Vx = Vx + Vy, set VF = carry
+		sum := uint16(c.V[x]) + uint16(c.V[y])
+		c.V[0xF] = uint8(sum >> 8)
+		c.V[x] = uint8(sum)
+		c.PC += 2
 
-		case 0x0007: // 8xy7 - SUBN Vx, Vy: Set Vx = Vy - Vx, set VF = NOT borrow
-			c.V[0xF] = 0
-			if c.V[y] > c.V[x] {
-				c.V[0xF] = 1
-			}
-			c.V[x] = c.V[y] - c.V[x]
-			c.PC += 2
+	case OpSUB: // 8xy5 - SUB Vx, Vy: Set Vx = Vx - Vy, set VF = NOT borrow
+		vx, vy := c.V[x], c.V[y]
+		c.V[0xF] = 0
+		if vx >= vy {
+			c.V[0xF] = 1
+		}
+		c.V[x] = vx - vy
+		c.PC += 2
 
-		case 0x000E: // 8xyE - SHL Vx: Set Vx = Vx SHL 1
-			c.V[0xF] = (c.V[x] & 0x80) >> 7
-			c.V[x] <<= 1
-			c.PC += 2
+	case OpSHR: // 8xy6 - SHR Vx: Set Vx = Vx SHR 1
+		src := c.V[x]
+		if c.Quirks.ShiftUsesVy {
+			src = c.V[y]
+		}
+		c.V[0xF] = src & 0x1
+		c.V[x] = src >> 1
+		c.PC += 2
 
-		default:
-			fmt.Printf("Unknown opcode: 0x%X\n", opcode)
-			c.PC += 2
+	case OpSUBN: // 8xy7 - SUBN Vx, Vy: Set Vx = Vy - Vx, set VF = NOT borrow
+		vx, vy := c.V[x], c.V[y]
+		c.V[0xF] = 0
+		if vy >= vx {
+			c.V[0xF] = 1
+		}
+		c.V[x] = vy - vx
+		c.PC += 2
+
+	case OpSHL: // 8xyE - SHL Vx: Set Vx = Vx SHL 1
+		src := c.V[x]
+		if c.Quirks.ShiftUsesVy {
+			src = c.V[y]
 		}
+		c.V[0xF] = (src & 0x80) >> 7
+		c.V[x] = src << 1
+		c.PC += 2
 
-	case 0x9000: // 9xy0 - SNE Vx, Vy: Skip next instruction if Vx != Vy
+	case OpSNEReg: // 9xy0 - SNE Vx, Vy: Skip next instruction if Vx != Vy
 		if c.V[x] != c.V[y] {
-			c.PC += 4
+			c.skipNext()
 		} else {
 			c.PC += 2
 		}
 
-	case 0xA000: // Annn - LD I, addr: Set I = nnn
+	case OpLDI: // Annn - LD I, addr: Set I = nnn
 		c.I = nnn
 		c.PC += 2
 
-	case 0xB000: // Bnnn - JP V0, addr: Jump to location nnn + V0
-		c.PC = nnn + uint16(c.V[0])
+	case OpJPV0: // Bnnn - JP V0, addr: Jump to location nnn + V0
+		if c.Quirks.JumpUsesVx {
+			c.PC = nnn + uint16(c.V[x]) // Bxnn: Jump to location xnn + Vx
+		} else {
+			c.PC = nnn + uint16(c.V[0])
+		}
 
-	case 0xC000: // Cxkk - RND Vx, byte: Set Vx = random byte AND kk
-		c.V[x] = uint8(rand.Intn(256)) & kk
+	case OpRND: // Cxkk - RND Vx, byte: Set Vx = random byte AND kk
+		c.V[x] = uint8(c.rng.Intn(256)) & kk
 		c.PC += 2
 
-	case 0xD000: // Dxyn - DRW Vx, Vy, n: Draw sprite at (Vx, Vy) with height n
-		c.drawSprite(x, y, n)
+	case OpDRW: // Dxyn - DRW Vx, Vy, n: Draw sprite at (Vx, Vy) with height n
+		if err := c.drawSprite(x, y, n); err != nil {
+			return err
+		}
 		c.PC += 2
+		c.waitingForVBlank = c.Quirks.DisplayWait
 
-	case 0xE000:
-		switch opcode & 0x00FF {
-		case 0x009E: // Ex9E - SKP Vx: Skip next instruction if key Vx is pressed
-			if c.keys[c.V[x]] {
-				c.PC += 4
-			} else {
-				c.PC += 2
-			}
-
-		case 0x00A1: // ExA1 - SKNP Vx: Skip next instruction if key Vx is not pressed
-			if !c.keys[c.V[x]] {
-				c.PC += 4
-			} else {
-				c.PC += 2
-			}
-
-		default:
-			fmt.Printf("Unknown opcode: 0x%X\n", opcode)
+	case OpSKP: // Ex9E - SKP Vx: Skip next instruction if key Vx is pressed
+		if c.keyDown(c.V[x]) {
+			c.skipNext()
+		} else {
 			c.PC += 2
 		}
 
-	case 0xF000:
-		switch opcode & 0x00FF {
-		case 0x0007: // Fx07 - LD Vx, DT: Set Vx = delay timer
-			c.V[x] = c.delayTimer
+	case OpSKNP: // ExA1 - SKNP Vx: Skip next instruction if key Vx is not pressed
+		if !c.keyDown(c.V[x]) {
+			c.skipNext()
+		} else {
 			c.PC += 2
+		}
 
-		case 0x000A: // Fx0A - LD Vx, K: Wait for key press, store in Vx
-			keyPressed := false
-			for i := 0; i < 16; i++ {
-				if c.keys[i] {
-					c.V[x] = uint8(i)
-					keyPressed = true
-					break
-				}
-			}
-			if keyPressed {
-				c.PC += 2
-			}
-			// If no key pressed, don't increment PC (wait)
+	case OpLDILong: // F000 nnnn - LD I, long: Set I = 16-bit nnnn from the next word (XO-CHIP)
+		c.I = c.readWord(c.PC + 2)
+		c.PC += 4
 
-		case 0x0015: // Fx15 - LD DT, Vx: Set delay timer = Vx
-			c.delayTimer = c.V[x]
-			c.PC += 2
+	case OpPLANE: // Fn01 - PLANE n: Select drawing planes n (XO-CHIP)
+		c.planes = x & 0x3
+		c.PC += 2
 
-		case 0x0018: // Fx18 - LD ST, Vx: Set sound timer = Vx
-			c.soundTimer = c.V[x]
-			c.PC += 2
+	case OpAUDIO: // F002 - AUDIO: Load 16 bytes at I into the audio pattern buffer (XO-CHIP)
+		if err := c.checkAccess(c.I, len(c.audioPattern)); err != nil {
+			return err
+		}
+		copy(c.audioPattern[:], c.memory[c.I:])
+		c.PC += 2
 
-		case 0x001E: // Fx1E - ADD I, Vx: Set I = I + Vx
-			c.I += uint16(c.V[x])
-			c.PC += 2
+	case OpLDVxDT: // Fx07 - LD Vx, DT: Set Vx = delay timer
+		c.V[x] = c.delayTimer
+		c.PC += 2
 
-		case 0x0029: // Fx29 - LD F, Vx: Set I = location of sprite for digit Vx
-			c.I = uint16(c.V[x]) * 5 // Each font character is 5 bytes
+	case OpLDVxK: // Fx0A - LD Vx, K: Wait for key press, store in Vx
+		if key, ok := c.waitForKey(); ok {
+			c.V[x] = key
 			c.PC += 2
+		}
+		// If no key pressed, don't increment PC (wait)
 
-		case 0x0033: // Fx33 - LD B, Vx: Store BCD representation of Vx in I, I+1, I+2
-			c.memory[c.I] = c.V[x] / 100
-			c.memory[c.I+1] = (c.V[x] / 10) % 10
-			c.memory[c.I+2] = c.V[x] % 10
-			c.PC += 2
+	case OpLDDT: // Fx15 - LD DT, Vx: Set delay timer = Vx
+		c.delayTimer = c.V[x]
+		c.PC += 2
 
-		case 0x0055: // Fx55 - LD [I], Vx: Store V0 through Vx in memory starting at I
-			for i := uint8(0); i <= x; i++ {
-				c.memory[c.I+uint16(i)] = c.V[i]
-			}
-			c.PC += 2
+	case OpLDST: // Fx18 - LD ST, Vx: Set sound timer = Vx
+		value := c.V[x]
+		if value > 0 && value < c.MinSoundFrames {
+			value = c.MinSoundFrames
+		}
+		c.setSoundTimer(value)
+		c.PC += 2
 
-		case 0x0065: // Fx65 - LD Vx, [I]: Read V0 through Vx from memory starting at I
-			for i := uint8(0); i <= x; i++ {
-				c.V[i] = c.memory[c.I+uint16(i)]
-			}
-			c.PC += 2
+	case OpADDI: // Fx1E - ADD I, Vx: Set I = I + Vx
+		sum := int(c.I) + int(c.V[x])
+		c.I = uint16(sum)
+		if c.Quirks.Fx1EOverflowSetsVF && sum > 0x0FFF {
+			c.V[0xF] = 1
+		}
+		c.PC += 2
 
-		default:
-			fmt.Printf("Unknown opcode: 0x%X\n", opcode)
-			c.PC += 2
+	case OpLDF: // Fx29 - LD F, Vx: Set I = location of sprite for digit Vx
+		c.I = c.glyphAddr(c.V[x])
+		c.PC += 2
+
+	case OpLDHF: // Fx30 - LD HF, Vx: Set I = location of large sprite for digit Vx (SUPER-CHIP)
+		c.I = BigFontStart + uint16(c.V[x]&0xF)*10 // Each large character is 10 bytes
+		c.PC += 2
+
+	case OpLDB: // Fx33 - LD B, Vx: Store BCD representation of Vx in I, I+1, I+2
+		if err := c.checkWrite(c.I, 3); err != nil {
+			return err
+		}
+		c.memory[c.I] = c.V[x] / 100
+		c.memory[c.I+1] = (c.V[x] / 10) % 10
+		c.memory[c.I+2] = c.V[x] % 10
+		c.PC += 2
+
+	case OpPITCH: // Fx3A - PITCH Vx: Set the audio pattern playback pitch = Vx (XO-CHIP)
+		c.pitch = c.V[x]
+		c.PC += 2
+
+	case OpStore: // Fx55 - LD [I], Vx: Store V0 through Vx in memory starting at I
+		if err := c.checkWrite(c.I, int(x)+1); err != nil {
+			return err
+		}
+		for i := uint8(0); i <= x; i++ {
+			c.memory[c.I+uint16(i)] = c.V[i]
+		}
+		if c.Quirks.LoadStoreIncrementsI {
+			c.I += uint16(x) + 1
+		}
+		c.PC += 2
+
+	case OpLoad: // Fx65 - LD Vx, [I]: Read V0 through Vx from memory starting at I
+		if err := c.checkAccess(c.I, int(x)+1); err != nil {
+			return err
+		}
+		for i := uint8(0); i <= x; i++ {
+			c.V[i] = c.memory[c.I+uint16(i)]
 		}
+		if c.Quirks.LoadStoreIncrementsI {
+			c.I += uint16(x) + 1
+		}
+		c.PC += 2
+
+	case OpStoreFlags: // Fx75 - LD R, Vx: Store V0 through Vx in the RPL user flags (SUPER-CHIP)
+		copy(c.rplFlags[:], c.V[:min(x, 7)+1])
+		c.PC += 2
+
+	case OpLoadFlags: // Fx85 - LD Vx, R: Read V0 through Vx from the RPL user flags (SUPER-CHIP)
+		copy(c.V[:], c.rplFlags[:min(x, 7)+1])
+		c.PC += 2
 
 	default:
-		fmt.Printf("Unknown opcode: 0x%X\n", opcode)
+		return c.unknownOpcode(opcode)
+	}
+
+	return nil
+}
+
+// OverrideOpcode replaces the built-in implementation of every opcode in
+// class, given as its leading nibble in place (e.g. 0xC000 for Cxkk), with
+// handler. The handler is responsible for all state changes, including
+// advancing PC, and its error is returned by EmulateCycle. To change only
+// some opcodes of a class, pass the rest to DefaultOpcodeHandler. Pass nil
+// to restore the built-in behavior
+func (c *Chip8) OverrideOpcode(class uint16, handler func(c *Chip8, opcode uint16) error) {
+	c.opcodeHandlers[class>>12] = handler
+}
+
+// DefaultOpcodeHandler returns the built-in implementation of the opcodes
+// in class, given as for OverrideOpcode, whether or not it is overridden
+// Opcodes from other classes are treated as unknown
+func DefaultOpcodeHandler(class uint16) func(c *Chip8, opcode uint16) error {
+	return func(c *Chip8, opcode uint16) error {
+		if opcode>>12 != class>>12 {
+			return c.unknownOpcode(opcode)
+		}
+		return c.executeBuiltin(opcode)
+	}
+}
+
+// SetUnknownOpcodeHandler registers a callback invoked with each opcode that
+// fails to decode. If it reports handled, it is responsible for any state
+// changes, PC moves past the opcode only when it also reports advance, and
+// EmulateCycle returns no error. Pass nil to restore the default error
+func (c *Chip8) SetUnknownOpcodeHandler(handler func(c *Chip8, opcode uint16) (handled bool, advance bool)) {
+	c.unknownHandler = handler
+}
+
+// unknownOpcode offers an opcode that failed to decode to the unknown
+// opcode handler, otherwise building its error and advancing past it when
+// SkipUnknownOpcodes is set
+func (c *Chip8) unknownOpcode(opcode uint16) error {
+	if c.unknownHandler != nil {
+		if handled, advance := c.unknownHandler(c, opcode); handled {
+			if advance {
+				c.PC += 2
+			}
+			return nil
+		}
+	}
+
+	err := &UnknownOpcodeError{Opcode: opcode, PC: c.PC}
+	if c.SkipUnknownOpcodes {
+		c.PC += 2
+	}
+	return err
+}
+
+// readWord reads the big-endian 16-bit word at addr, wrapping at the end
+// of memory
+func (c *Chip8) readWord(addr uint16) uint16 {
+	size := len(c.memory)
+	return uint16(c.memory[int(addr)%size])<<8 | uint16(c.memory[(int(addr)+1)%size])
+}
+
+// skipNext advances PC past the current instruction and the one after it,
+// which is 4 bytes long if it is an XO-CHIP F000 nnnn
+func (c *Chip8) skipNext() {
+	c.PC += 2
+	if c.readWord(c.PC) == 0xF000 {
+		c.PC += 4
+	} else {
 		c.PC += 2
 	}
 }
 
 // drawSprite draws a sprite at coordinates (Vx, Vy) with height n
-func (c *Chip8) drawSprite(x, y, height uint8) {
+// A height of 0 draws a 16x16 sprite (SUPER-CHIP) stored as 16 rows of 2 bytes
+// Each selected plane (XO-CHIP) is drawn in turn, with the sprite data for
+// plane 2 following the data for plane 1 when both are selected
+//
+// VF is 1 if any drawn pixel collided, or with CollisionCountsRows in
+// high-resolution mode the number of rows that collided or were clipped
+// off the bottom. VF only reflects pixels actually drawn: with
+// ClipSprites, off-screen pixels are skipped before the collision check.
+// A wrapped sprite can't collide with itself, since sprites are at most 16
+// pixels on a side and the screen is at least 32 pixels in each direction
+func (c *Chip8) drawSprite(x, y, height uint8) error {
+	spriteWidth, rows := 8, int(height)
+	if height == 0 {
+		spriteWidth, rows = 16, 16
+	}
+
+	planeBytes := rows * spriteWidth / 8
+	planeCount := int(c.planes&1 + c.planes>>1&1)
+	if err := c.checkAccess(c.I, planeBytes*planeCount); err != nil {
+		return err
+	}
+
+	c.drawSpriteRows(x, y, spriteWidth, rows, c.memory[c.I:int(c.I)+planeBytes*planeCount])
+	return nil
+}
+
+// DrawSpriteData draws data as a sprite at (V[vx], V[vy]) exactly as Dxyn
+// would if data were at I, without touching memory or I, and returns the
+// collision flag it also stores in VF. Each byte is one 8-pixel row; with
+// both XO-CHIP planes selected the first half of data is drawn to plane 1
+// and the second half to plane 2. vx and vy are taken modulo 16, and rows
+// past the 16th in each plane are ignored. It is meant for display tests
+func (c *Chip8) DrawSpriteData(vx, vy uint8, data []byte) uint8 {
+	bothPlanes := c.planes&0x3 == 0x3
+	planeRows := len(data)
+	if bothPlanes {
+		planeRows /= 2
+	}
+
+	// Keep the planes back to back, as drawSpriteRows expects
+	rows := min(planeRows, 16)
+	sprite := data[:rows]
+	if bothPlanes {
+		sprite = append(sprite[:rows:rows], data[planeRows:planeRows+rows]...)
+	}
+
+	c.drawSpriteRows(vx&0xF, vy&0xF, 8, rows, sprite)
+	return c.V[0xF]
+}
+
+// drawSpriteRows draws rows rows of spriteWidth (8 or 16) pixels from data
+// at (Vx, Vy) in each selected plane, setting VF as described on drawSprite
+// data holds each plane's rows in turn
+func (c *Chip8) drawSpriteRows(x, y uint8, spriteWidth, rows int, data []byte) {
+	planeBytes := rows * spriteWidth / 8
+
 	c.V[0xF] = 0 // Reset collision flag
 
-	xPos := c.V[x] % ScreenWidth
-	yPos := c.V[y] % ScreenHeight
+	width, screenHeight := c.GetDisplaySize()
+	xPos := int(c.V[x]) % width
+	yPos := int(c.V[y]) % screenHeight
+
+	// Sprite rows that collided or were clipped off the bottom, for the
+	// SUPER-CHIP row-count collision mode
+	var rowHits [16]bool
+	collided := false
+
+	offset := 0
+	for plane := uint8(1); plane <= 2; plane <<= 1 {
+		if c.planes&plane == 0 {
+			continue
+		}
 
-	for row := uint8(0); row < height; row++ {
-		spriteData := c.memory[c.I+uint16(row)]
+		for row := 0; row < rows; row++ {
+			if c.Quirks.ClipSprites && yPos+row >= screenHeight {
+				rowHits[row] = true
+				continue
+			}
 
-		for col := uint8(0); col < 8; col++ {
-			// Check if current pixel in sprite is set
-			if (spriteData & (0x80 >> col)) != 0 {
-				// Calculate screen position
-				screenX := (xPos + col) % ScreenWidth
-				screenY := (yPos + row) % ScreenHeight
-				pixelIndex := screenY*ScreenWidth + screenX
+			// Left-align the row's sprite data in 16 bits
+			var spriteData uint16
+			if spriteWidth == 16 {
+				rowOffset := offset + row*2
+				spriteData = uint16(data[rowOffset])<<8 | uint16(data[rowOffset+1])
+			} else {
+				spriteData = uint16(data[offset+row]) << 8
+			}
 
-				// Check for collision (pixel already set)
-				if c.display[pixelIndex] == 1 {
-					c.V[0xF] = 1
+			for col := 0; col < spriteWidth; col++ {
+				// Check if current pixel in sprite is set
+				if (spriteData & (0x8000 >> col)) != 0 {
+					// Calculate screen position
+					screenX := xPos + col
+					screenY := yPos + row
+					if c.Quirks.ClipSprites && (screenX >= width || screenY >= screenHeight) {
+						continue // Clipped pixels never set VF
+					}
+					screenX %= width
+					screenY %= screenHeight
+
+					// XOR the pixel, checking for collision (pixel already
+					// set in this plane)
+					if c.renderer.SetPixel(screenX, screenY, plane) {
+						c.V[0xF] = 1
+						rowHits[row] = true
+						collided = true
+					}
+					c.markDirty(screenX, screenY)
 				}
+			}
+		}
 
-				// XOR the pixel
-				c.display[pixelIndex] ^= 1
+		offset += planeBytes
+	}
+
+	if c.Quirks.CollisionCountsRows && c.hires {
+		c.V[0xF] = 0
+		for _, hit := range rowHits {
+			if hit {
+				c.V[0xF]++
 			}
 		}
 	}
 
-	c.drawFlag = true
+	if collided && c.collisionCallback != nil {
+		c.collisionCallback(uint8(xPos), uint8(yPos))
+	}
+
+	c.markDrawn()
+}
+
+// clearScreen clears the selected planes of the display
+func (c *Chip8) clearScreen() {
+	c.renderer.Clear(c.planes)
+	if c.customRenderer() {
+		c.markAllDirty()
+	}
+	c.markDrawn()
+}
+
+// scroll shifts the selected planes of the display by (dx, dy) pixels at
+// the active resolution, zero-filling the vacated rows and columns
+func (c *Chip8) scroll(dx, dy int) {
+	c.renderer.Scroll(dx, dy, c.planes)
+	if c.customRenderer() {
+		c.markAllDirty()
+	}
+	c.markDrawn()
+}
+
+// waitForKey polls the keypad for Fx0A, reporting the key that completes
+// the wait. With WaitForKeyRelease the wait completes when a key that was
+// down during the wait is released, otherwise on any held key, which is
+// then consumed so that it doesn't register again until released
+func (c *Chip8) waitForKey() (uint8, bool) {
+	if !c.Quirks.WaitForKeyRelease {
+		for i := uint8(0); i < 16; i++ {
+			if c.keyDown(i) {
+				c.consumedKeys[i] = true
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	if !c.waitingForKey {
+		c.waitingForKey = true
+		c.waitKeysDown = [16]bool{}
+	}
+
+	for i := 0; i < 16; i++ {
+		if c.keys[i] {
+			c.waitKeysDown[i] = true
+		} else if c.waitKeysDown[i] {
+			c.waitingForKey = false
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+// keyDown reports whether the program sees key as held: it is pressed
+// and not consumed by Fx0A. Out-of-range keys are never down
+func (c *Chip8) keyDown(key uint8) bool {
+	return key < 16 && c.keys[key] && !c.consumedKeys[key]
+}
+
+// setHiRes switches display resolution, clearing the screen
+func (c *Chip8) setHiRes(hires bool) {
+	c.hires = hires
+	c.renderer.Resize(c.GetDisplaySize())
+	c.markAllDirty()
+	c.markDrawn()
+}
+
+// setSoundTimer updates the sound timer, notifying the sound handler when
+// the beep starts (0 to nonzero) or stops (nonzero to 0)
+func (c *Chip8) setSoundTimer(value uint8) {
+	wasActive := c.soundTimer > 0
+	c.soundTimer = value
+
+	if active := value > 0; active != wasActive && c.soundHandler != nil && !c.inFrame && !c.paused {
+		c.soundHandler(active)
+	}
+}
+
+// SetCollisionCallback registers a callback fired once for every sprite
+// draw that collides with a lit pixel, with the wrapped screen position the
+// sprite was drawn at. Pass nil to remove it
+func (c *Chip8) SetCollisionCallback(callback func(x, y uint8)) {
+	c.collisionCallback = callback
+}
+
+// SetSoundHandler registers a callback fired when the beep starts
+// (playing = true) or stops (playing = false). Pass nil to remove it
+// It has no effect on an emulator created with NewHeadless
+func (c *Chip8) SetSoundHandler(handler func(playing bool)) {
+	if c.headless {
+		return
+	}
+	c.soundHandler = handler
+}
+
+// SoundActive reports whether the beep should currently be playing, which
+// it never is while paused
+func (c *Chip8) SoundActive() bool {
+	return c.soundTimer > 0 && !c.paused
+}
+
+// AudioPattern returns the XO-CHIP audio pattern buffer, 128 1-bit
+// samples played most significant bit first
+func (c *Chip8) AudioPattern() [16]uint8 {
+	return c.audioPattern
+}
+
+// AudioPitch returns the XO-CHIP pitch register set by Fx3A, controlling
+// the pattern playback rate. It defaults to 64 (4000 samples per second)
+func (c *Chip8) AudioPitch() uint8 {
+	return c.pitch
+}
+
+// SoundFrequency returns the rate in Hz at which the XO-CHIP audio pattern
+// is played, 4000*2^((pitch-64)/48). Each pattern bit is one sample, so a
+// frontend resamples AudioPattern at this rate while SoundActive
+func (c *Chip8) SoundFrequency() float64 {
+	return 4000 * math.Pow(2, (float64(c.pitch)-64)/48)
 }
 
 // SetKey sets the state of a key
 func (c *Chip8) SetKey(key uint8, pressed bool) {
 	if key < 16 {
 		c.keys[key] = pressed
+		if !pressed {
+			c.consumedKeys[key] = false
+		}
+		if c.recording != nil {
+			c.recordKey(key, pressed)
+		}
+	}
+}
+
+// KeyState returns a copy of the pressed state of all 16 keys
+func (c *Chip8) KeyState() [16]bool {
+	return c.keys
+}
+
+// IsKeyPressed reports whether key is held; out-of-range keys are never pressed
+func (c *Chip8) IsKeyPressed(key uint8) bool {
+	if key < 16 {
+		return c.keys[key]
+	}
+	return false
+}
+
+// KeysHeld returns the held keys in ascending order
+func (c *Chip8) KeysHeld() []uint8 {
+	var held []uint8
+	for key, pressed := range c.keys {
+		if pressed {
+			held = append(held, uint8(key))
+		}
+	}
+	return held
+}
+
+// ChordPressed reports whether every one of keys is held, regardless of any
+// other held keys. An empty chord is never pressed
+func (c *Chip8) ChordPressed(keys ...uint8) bool {
+	for _, key := range keys {
+		if !c.IsKeyPressed(key) {
+			return false
+		}
 	}
+	return len(keys) > 0
 }
 
-// GetDisplay returns the current display state
+// Planes returns the XO-CHIP plane mask selected by Fn01
+func (c *Chip8) Planes() uint8 {
+	return c.planes
+}
+
+// GetDisplay returns the current display state at 64x32
+// Each pixel carries one bit per XO-CHIP plane, so monochrome programs only
+// ever produce 0 and 1
+// In high-resolution mode each pixel is set if any pixel of the
+// corresponding 2x2 block is set; use GetActiveDisplay for the full frame
 func (c *Chip8) GetDisplay() [ScreenWidth * ScreenHeight]uint8 {
-	return c.display
+	var display [ScreenWidth * ScreenHeight]uint8
+
+	if !c.hires {
+		copy(display[:], c.display[:])
+		return display
+	}
+
+	for y := 0; y < HiResHeight; y++ {
+		for x := 0; x < HiResWidth; x++ {
+			display[(y/2)*ScreenWidth+x/2] |= c.display[y*HiResWidth+x]
+		}
+	}
+	return display
 }
 
-// DrawFlag returns and resets the draw flag
+// GetActiveDisplay returns a copy of the display at the active resolution,
+// row-major with the width reported by GetDisplaySize
+func (c *Chip8) GetActiveDisplay() []uint8 {
+	width, height := c.GetDisplaySize()
+	display := make([]uint8, width*height)
+	copy(display, c.display[:])
+	return display
+}
+
+// DisplayBuffer returns the display at the active resolution like
+// GetActiveDisplay, but as a slice aliasing the emulator's own buffer so
+// rendering needs no copy. It is read-only: writing to it corrupts the
+// display. Its contents change as instructions run, and it must be fetched
+// again after the resolution changes
+func (c *Chip8) DisplayBuffer() []uint8 {
+	width, height := c.GetDisplaySize()
+	size := width * height
+	return c.display[:size:size]
+}
+
+// GetDisplaySize returns the active display resolution
+func (c *Chip8) GetDisplaySize() (width, height int) {
+	if c.hires {
+		return HiResWidth, HiResHeight
+	}
+	return ScreenWidth, ScreenHeight
+}
+
+// Width returns the active display width: ScreenWidth, or HiResWidth in
+// high-resolution mode
+func (c *Chip8) Width() int {
+	width, _ := c.GetDisplaySize()
+	return width
+}
+
+// Height returns the active display height: ScreenHeight, or HiResHeight
+// in high-resolution mode
+func (c *Chip8) Height() int {
+	_, height := c.GetDisplaySize()
+	return height
+}
+
+// HiRes reports whether SUPER-CHIP high-resolution mode is active
+func (c *Chip8) HiRes() bool {
+	return c.hires
+}
+
+// markDrawn sets the draw flag and notifies the draw callback, or defers
+// the notification to the end of the frame inside RunFrame
+func (c *Chip8) markDrawn() {
+	c.drawFlag = true
+	c.drawCount++
+	if c.inFrame {
+		c.frameDrawn = true
+	} else if c.drawCallback != nil {
+		c.drawCallback(c.GetDisplay())
+	}
+}
+
+// SetDrawCallback registers a callback invoked with a snapshot of the
+// display (as returned by GetDisplay) each time the screen changes
+// The draw flag is still set, so DrawFlag polling keeps working
+// Pass nil to remove the callback. It has no effect on an emulator
+// created with NewHeadless
+func (c *Chip8) SetDrawCallback(callback func(display [ScreenWidth * ScreenHeight]uint8)) {
+	if c.headless {
+		return
+	}
+	c.drawCallback = callback
+}
+
+// DrawFlag returns and resets the draw flag, also clearing the dirty region
+// This consumes the flag: only the render loop should call it. Use
+// PeekDrawFlag to inspect the flag without losing a pending redraw
 func (c *Chip8) DrawFlag() bool {
 	flag := c.drawFlag
 	c.drawFlag = false
+	c.dirty = false
 	return flag
 }
+
+// PeekDrawFlag returns the draw flag without resetting it
+func (c *Chip8) PeekDrawFlag() bool {
+	return c.drawFlag
+}
+
+// Registers returns a copy of V0-VF
+func (c *Chip8) Registers() [RegisterCount]uint8 {
+	return c.V
+}
+
+// Index returns the I register
+func (c *Chip8) Index() uint16 {
+	return c.I
+}
+
+// ProgramCounter returns the address of the next instruction
+func (c *Chip8) ProgramCounter() uint16 {
+	return c.PC
+}
+
+// StackPointer returns the number of return addresses on the stack
+func (c *Chip8) StackPointer() uint8 {
+	return c.SP
+}
+
+// Stack returns a copy of the call stack
+func (c *Chip8) Stack() [StackSize]uint16 {
+	return c.stack
+}
+
+// RPLFlags returns the SUPER-CHIP RPL user flags
+func (c *Chip8) RPLFlags() [8]uint8 {
+	return c.rplFlags
+}
+
+// DelayTimer returns the current delay timer value
+func (c *Chip8) DelayTimer() uint8 {
+	return c.delayTimer
+}
+
+// SoundTimer returns the current sound timer value
+func (c *Chip8) SoundTimer() uint8 {
+	return c.soundTimer
+}
+
+// SetDelayTimer sets the delay timer to v
+func (c *Chip8) SetDelayTimer(v uint8) {
+	c.delayTimer = v
+}
+
+// SetSoundTimer sets the sound timer to v, notifying the sound handler if
+// this starts or stops the beep. MinSoundFrames is not applied
+func (c *Chip8) SetSoundTimer(v uint8) {
+	c.setSoundTimer(v)
+}
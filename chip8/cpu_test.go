@@ -0,0 +1,292 @@
+package chip8
+
+import (
+	"errors"
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+// loadProgram returns a deterministic emulator with opcodes loaded as its ROM
+func loadProgram(t *testing.T, opcodes ...uint16) *Chip8 {
+	t.Helper()
+	rom := make([]byte, 0, 2*len(opcodes))
+	for _, opcode := range opcodes {
+		rom = append(rom, byte(opcode>>8), byte(opcode))
+	}
+
+	c := NewWithSeed(1)
+	if err := c.LoadROM(rom); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	return c
+}
+
+// step runs n cycles, failing the test on the first error
+func step(t *testing.T, c *Chip8, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := c.EmulateCycle(); err != nil {
+			t.Fatalf("cycle %d at PC 0x%03X: %v", i, c.PC, err)
+		}
+	}
+}
+
+func TestArithmeticFlagOrdering(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint16
+		v3, vf uint8
+		want3  uint8 // V3 afterwards
+		wantVF uint8
+	}{
+		// The flag is set from the operands, then the result is stored,
+		// so with x == F the result replaces the flag
+		{"ADD VF, V3", 0x8F34, 0x03, 0xFF, 0x03, 0x02},
+		{"SUB VF, V3", 0x8F35, 0x03, 0x05, 0x03, 0x02},
+		{"SHR VF", 0x8F06, 0x00, 0x81, 0x00, 0x40},
+		{"SUBN VF, V3", 0x8F37, 0x05, 0x03, 0x05, 0x02},
+		{"SHL VF", 0x8F0E, 0x00, 0x81, 0x00, 0x02},
+
+		// With y == F the old VF is the operand, not the new flag
+		{"ADD V3, VF", 0x83F4, 0x01, 0xFF, 0x00, 0x01},
+		{"SUB V3, VF", 0x83F5, 0x05, 0x09, 0xFC, 0x00},
+		{"SUBN V3, VF", 0x83F7, 0x05, 0x09, 0x04, 0x01},
+
+		// Equal operands don't borrow
+		{"SUB equal", 0x8335, 0x05, 0x00, 0x00, 0x01},
+		{"SUBN equal", 0x8337, 0x05, 0x00, 0x00, 0x01},
+
+		{"ADD no carry", 0x8334, 0x7F, 0x05, 0xFE, 0x00},
+		{"SUB borrow", 0x8F35, 0x06, 0x05, 0x06, 0xFF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := loadProgram(t, tt.opcode)
+			c.V[3], c.V[0xF] = tt.v3, tt.vf
+			step(t, c, 1)
+
+			if c.V[3] != tt.want3 || c.V[0xF] != tt.wantVF {
+				t.Errorf("V3, VF = 0x%02X, 0x%02X; want 0x%02X, 0x%02X", c.V[3], c.V[0xF], tt.want3, tt.wantVF)
+			}
+		})
+	}
+}
+
+func TestOverrideOpcode(t *testing.T) {
+	c := loadProgram(t, 0xC3AB, 0xC4CD, 0xC5EF) // RND V3..V5
+	c.OverrideOpcode(0xC000, func(c *Chip8, opcode uint16) error {
+		if opcode == 0xC5EF {
+			return errors.New("no V5")
+		}
+		c.V[opcode>>8&0xF] = uint8(opcode) // Deterministic RND for tests
+		c.PC += 2
+		return nil
+	})
+
+	step(t, c, 2)
+	if c.V[3] != 0xAB || c.V[4] != 0xCD {
+		t.Errorf("V3, V4 = %02X, %02X, want AB, CD", c.V[3], c.V[4])
+	}
+	if err := c.EmulateCycle(); err == nil || err.Error() != "no V5" {
+		t.Errorf("EmulateCycle() = %v, want the handler's error", err)
+	}
+	if c.PC != 0x204 {
+		t.Errorf("PC = %03X after the failed handler, want 204", c.PC)
+	}
+
+	c.OverrideOpcode(0xC000, nil)
+	step(t, c, 1)
+	if c.PC != 0x206 || c.V[5] & ^uint8(0xEF) != 0 {
+		t.Errorf("PC = %03X V5 = %02X, want built-in RND masked by EF", c.PC, c.V[5])
+	}
+}
+
+func TestStackBounds(t *testing.T) {
+	c := loadProgram(t, 0x2200) // CALL 0x200, recursing forever
+	step(t, c, StackSize)
+	if err := c.EmulateCycle(); !errors.Is(err, ErrStackOverflow) {
+		t.Errorf("17th CALL: EmulateCycle() = %v, want ErrStackOverflow", err)
+	}
+	if int(c.SP) != StackSize {
+		t.Errorf("SP = %d, want %d", c.SP, StackSize)
+	}
+
+	c = loadProgram(t, 0x00EE) // RET with an empty stack
+	if err := c.EmulateCycle(); !errors.Is(err, ErrStackUnderflow) {
+		t.Errorf("RET: EmulateCycle() = %v, want ErrStackUnderflow", err)
+	}
+	if c.SP != 0 {
+		t.Errorf("SP = %d after the failed RET, want 0", c.SP)
+	}
+}
+
+func TestPCOutOfBounds(t *testing.T) {
+	c := loadProgram(t)
+	c.PC = 0xFFF
+	if err := c.EmulateCycle(); !errors.Is(err, ErrPCOutOfBounds) {
+		t.Errorf("EmulateCycle() at 0xFFF = %v, want ErrPCOutOfBounds", err)
+	}
+
+	c.Quirks.WrapPC = true
+	c.PC = 0x1000                         // Wraps to 0x000
+	c.memory[0], c.memory[1] = 0x60, 0x42 // LD V0, 0x42
+	if err := c.EmulateCycle(); err != nil {
+		t.Fatalf("EmulateCycle() with WrapPC = %v", err)
+	}
+	if c.V[0] != 0x42 || c.PC != 0x002 {
+		t.Errorf("V0 = %02X PC = %03X, want 42 002 after wrapping", c.V[0], c.PC)
+	}
+}
+
+func TestLongLoadI(t *testing.T) {
+	c := loadProgram(t, 0xF000, 0x0ABC, 0x6001)
+	step(t, c, 1)
+	if c.I != 0x0ABC || c.PC != 0x204 {
+		t.Errorf("I = %04X PC = %03X, want 0ABC 204", c.I, c.PC)
+	}
+
+	// Skips step over both words of F000 nnnn
+	c = loadProgram(t, 0x3000, 0xF000, 0x0ABC, 0x6001) // SE V0, 0
+	step(t, c, 2)
+	if c.PC != 0x208 || c.V[0] != 1 || c.I != 0 {
+		t.Errorf("PC = %03X V0 = %d I = %04X, want 208 1 0 after skipping F000", c.PC, c.V[0], c.I)
+	}
+}
+
+func TestRPLFlags(t *testing.T) {
+	c := loadProgram(t,
+		0x6011, 0x6122, 0x6233, // LD V0..V2
+		0xF275,                 // LD R, V2
+		0x6000, 0x6100, 0x6200, // Clear V0..V2
+		0xF185, // LD V1, R
+	)
+	step(t, c, 4)
+	if got := c.RPLFlags(); got != [8]uint8{0x11, 0x22, 0x33} {
+		t.Errorf("RPLFlags() = % X, want 11 22 33 0 ...", got)
+	}
+
+	step(t, c, 4)
+	if c.V[0] != 0x11 || c.V[1] != 0x22 || c.V[2] != 0 {
+		t.Errorf("V0..V2 = % X, want 11 22 00", c.V[:3])
+	}
+
+	c.Reset()
+	if got := c.RPLFlags(); got[0] != 0x11 {
+		t.Error("Reset cleared the RPL flags")
+	}
+
+	// x is bounded to 7
+	c = loadProgram(t, 0x6F99, 0x6777, 0xFF75)
+	step(t, c, 3)
+	if got := c.RPLFlags(); got[7] != 0x77 {
+		t.Errorf("RPLFlags()[7] = %02X after FF75, want 77", got[7])
+	}
+}
+
+func TestKeysHeldAndChords(t *testing.T) {
+	c := NewWithSeed(1)
+	if held := c.KeysHeld(); held != nil {
+		t.Errorf("KeysHeld() = %v with no keys held, want nil", held)
+	}
+	for _, key := range []uint8{0xC, 3, 1, 2} {
+		c.SetKey(key, true)
+	}
+	if held := c.KeysHeld(); !slices.Equal(held, []uint8{1, 2, 3, 0xC}) {
+		t.Errorf("KeysHeld() = %v, want [1 2 3 12]", held)
+	}
+
+	tests := []struct {
+		keys []uint8
+		want bool
+	}{
+		{[]uint8{1, 2, 3}, true}, // Extra held keys don't matter
+		{[]uint8{3, 1}, true},
+		{[]uint8{1, 2, 4}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := c.ChordPressed(tt.keys...); got != tt.want {
+			t.Errorf("ChordPressed(%v) = %v, want %v", tt.keys, got, tt.want)
+		}
+	}
+
+	c.SetKey(2, false)
+	if c.ChordPressed(1, 2, 3) {
+		t.Error("ChordPressed(1, 2, 3) = true after releasing 2")
+	}
+}
+
+func TestHeadlessWritesNothingToStdout(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	c := NewHeadless()
+	c.SetDrawCallback(func([ScreenWidth * ScreenHeight]uint8) { t.Error("draw callback called in headless mode") })
+	c.SetSoundHandler(func(bool) { t.Error("sound handler called in headless mode") })
+	if err := c.LoadROM([]byte{
+		0x60, 0x05, 0xF0, 0x18, // LD V0, 5; LD ST, V0
+		0xF0, 0x29, 0xD1, 0x25, // LD F, V0; DRW V1, V2, 5
+		0xFF, 0xFF, // Unknown: reported, not printed
+		0x12, 0x0A,
+	}); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	var unknown *UnknownOpcodeError
+	for range 10 {
+		if err := c.RunFrame(DefaultCyclesPerFrame); err != nil && !errors.As(err, &unknown) {
+			t.Fatalf("RunFrame: %v", err)
+		}
+	}
+	if unknown == nil {
+		t.Error("the unknown opcode wasn't reported as an error")
+	}
+	w.Close()
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 {
+		t.Errorf("wrote %q to stdout", out)
+	}
+	if c.DisplayHash() == NewHeadless().DisplayHash() {
+		t.Error("headless display buffer wasn't updated")
+	}
+}
+
+func TestOverrideOpcodeDelegates(t *testing.T) {
+	// A teaching variant: 8xy6 rotates right, the rest of 8xyn is unchanged
+	builtin := DefaultOpcodeHandler(0x8000)
+	c := loadProgram(t, 0x6081, 0x6105, 0x8016, 0x8014) // LD V0, 0x81; LD V1, 5; SHR V0; ADD V0, V1
+	c.OverrideOpcode(0x8000, func(c *Chip8, opcode uint16) error {
+		if opcode&0xF != 0x6 {
+			return builtin(c, opcode)
+		}
+		x := opcode >> 8 & 0xF
+		c.V[x] = c.V[x]>>1 | c.V[x]<<7
+		c.PC += 2
+		return nil
+	})
+
+	step(t, c, 3)
+	if c.V[0] != 0xC0 {
+		t.Errorf("V0 = %02X after the overridden SHR, want C0", c.V[0])
+	}
+	step(t, c, 1)
+	if c.V[0] != 0xC5 || c.PC != 0x208 {
+		t.Errorf("V0 = %02X PC = %03X after the built-in ADD, want C5 208", c.V[0], c.PC)
+	}
+
+	var unknown *UnknownOpcodeError
+	if err := builtin(c, 0x1200); !errors.As(err, &unknown) {
+		t.Errorf("DefaultOpcodeHandler(0x8000)(1200) = %v, want an *UnknownOpcodeError", err)
+	}
+}
@@ -0,0 +1,311 @@
+package chip8
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SetTraceFunc registers a callback invoked by EmulateCycle after each opcode
+// is fetched and before it executes, receiving the address it was fetched
+// from and the opcode itself. Pass nil to disable tracing
+func (c *Chip8) SetTraceFunc(trace func(pc uint16, opcode uint16)) {
+	c.traceFunc = trace
+}
+
+// SetLogWriter makes EmulateCycle write one line per executed instruction
+// to w, with tab-separated fields: the cycle number, PC, opcode, mnemonic
+// and the registers the instruction changed, e.g.
+//
+//	12	0x0204	0x7001	ADD V0, 0x01	V0=0x06
+//
+// Changed registers are listed as space-separated NAME=VALUE pairs in the
+// order V0-VF, I; the field is empty if none changed. Write errors are
+// ignored. Pass nil to stop logging
+func (c *Chip8) SetLogWriter(w io.Writer) {
+	c.logWriter = w
+}
+
+// executeLogged executes opcode and writes its line to the log writer
+func (c *Chip8) executeLogged(opcode uint16) error {
+	pc := c.PC
+	mnemonic := c.mnemonicAt(pc)
+	v, i := c.V, c.I
+
+	err := c.executeOpcode(opcode)
+
+	var changes []string
+	for r := range c.V {
+		if c.V[r] != v[r] {
+			changes = append(changes, fmt.Sprintf("V%X=0x%02X", r, c.V[r]))
+		}
+	}
+	if c.I != i {
+		changes = append(changes, fmt.Sprintf("I=0x%04X", c.I))
+	}
+	fmt.Fprintf(c.logWriter, "%d\t0x%04X\t0x%04X\t%s\t%s\n", c.cycles, pc, opcode, mnemonic, strings.Join(changes, " "))
+
+	return err
+}
+
+// ErrBreakpoint is returned by EmulateCycle when PC reaches a breakpoint
+var ErrBreakpoint = errors.New("breakpoint hit")
+
+// AddBreakpoint makes execution stop before the instruction at addr
+func (c *Chip8) AddBreakpoint(addr uint16) {
+	if c.breakpoints == nil {
+		c.breakpoints = make(map[uint16]bool)
+	}
+	c.breakpoints[addr] = true
+}
+
+// RemoveBreakpoint removes the breakpoint at addr, if any
+func (c *Chip8) RemoveBreakpoint(addr uint16) {
+	delete(c.breakpoints, addr)
+}
+
+// opcodeBreakpoint stops execution before any opcode with
+// opcode&mask == match
+type opcodeBreakpoint struct {
+	mask, match uint16
+}
+
+// AddOpcodeBreakpoint makes execution stop before any instruction whose
+// opcode satisfies opcode&mask == match, e.g. mask 0xF000 and match 0xD000
+// for every draw
+func (c *Chip8) AddOpcodeBreakpoint(mask, match uint16) {
+	bp := opcodeBreakpoint{mask: mask, match: match}
+	if !slices.Contains(c.opcodeBreakpoints, bp) {
+		c.opcodeBreakpoints = append(c.opcodeBreakpoints, bp)
+	}
+}
+
+// RemoveOpcodeBreakpoint removes the opcode breakpoint added with the same
+// mask and match, if any
+func (c *Chip8) RemoveOpcodeBreakpoint(mask, match uint16) {
+	c.opcodeBreakpoints = slices.DeleteFunc(c.opcodeBreakpoints, func(bp opcodeBreakpoint) bool {
+		return bp == opcodeBreakpoint{mask: mask, match: match}
+	})
+}
+
+// ClearBreakpoints removes all address and opcode breakpoints
+func (c *Chip8) ClearBreakpoints() {
+	c.breakpoints = nil
+	c.opcodeBreakpoints = nil
+}
+
+// opcodeBreakpointHit reports whether opcode matches an opcode breakpoint
+func (c *Chip8) opcodeBreakpointHit(opcode uint16) bool {
+	for _, bp := range c.opcodeBreakpoints {
+		if opcode&bp.mask == bp.match {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMemoryWatch reports changes to the byte at addr made by executed
+// instructions to the watch callback
+func (c *Chip8) AddMemoryWatch(addr uint16) {
+	if i, found := slices.BinarySearch(c.memoryWatches, addr); !found {
+		c.memoryWatches = slices.Insert(c.memoryWatches, i, addr)
+	}
+}
+
+// RemoveMemoryWatch stops watching the byte at addr
+func (c *Chip8) RemoveMemoryWatch(addr uint16) {
+	if i, found := slices.BinarySearch(c.memoryWatches, addr); found {
+		c.memoryWatches = slices.Delete(c.memoryWatches, i, i+1)
+	}
+}
+
+// AddRegisterWatch reports changes to register Vreg made by executed
+// instructions to the watch callback. Registers above 0xF are ignored
+func (c *Chip8) AddRegisterWatch(reg uint8) {
+	if reg < RegisterCount {
+		c.registerWatches |= 1 << reg
+	}
+}
+
+// RemoveRegisterWatch stops watching register Vreg
+func (c *Chip8) RemoveRegisterWatch(reg uint8) {
+	if reg < RegisterCount {
+		c.registerWatches &^= 1 << reg
+	}
+}
+
+// ClearWatches removes all memory and register watches
+func (c *Chip8) ClearWatches() {
+	c.memoryWatches = nil
+	c.registerWatches = 0
+}
+
+// SetWatchCallback registers the callback invoked after an instruction
+// changes a watched location, with kind "memory" and the address or kind
+// "register" and the register number, and the values before and after
+// Changes made through WriteMemory and the like are not reported
+// Pass nil to remove it
+func (c *Chip8) SetWatchCallback(callback func(kind string, index int, old, new uint8)) {
+	c.watchCallback = callback
+}
+
+// executeWatched executes opcode and reports changes to watched locations
+func (c *Chip8) executeWatched(opcode uint16) error {
+	v := c.V
+	mem := make([]uint8, len(c.memoryWatches))
+	for i, addr := range c.memoryWatches {
+		if int(addr) < len(c.memory) {
+			mem[i] = c.memory[addr]
+		}
+	}
+
+	err := c.execute(opcode)
+	if c.watchCallback == nil {
+		return err
+	}
+
+	for i, addr := range c.memoryWatches {
+		if int(addr) < len(c.memory) && c.memory[addr] != mem[i] {
+			c.watchCallback("memory", int(addr), mem[i], c.memory[addr])
+		}
+	}
+	for r := range c.V {
+		if c.registerWatches&(1<<r) != 0 && c.V[r] != v[r] {
+			c.watchCallback("register", r, v[r], c.V[r])
+		}
+	}
+	return err
+}
+
+// opcodeClasses names the instruction class of each leading opcode nibble
+var opcodeClasses = [16]string{
+	0x0: "system",
+	0x1: "jump",
+	0x2: "call",
+	0x3: "skip",
+	0x4: "skip",
+	0x5: "skip",
+	0x6: "load",
+	0x7: "arithmetic",
+	0x8: "arithmetic",
+	0x9: "skip",
+	0xA: "load",
+	0xB: "jump",
+	0xC: "random",
+	0xD: "draw",
+	0xE: "input",
+	0xF: "misc",
+}
+
+// CycleCount returns the number of instructions executed since New or Reset
+func (c *Chip8) CycleCount() uint64 {
+	return c.cycles
+}
+
+// LastOpcode returns the address and opcode of the last instruction
+// EmulateCycle executed, or zeros if none has run since New or Reset
+func (c *Chip8) LastOpcode() (pc uint16, opcode uint16) {
+	return c.lastPC, c.lastOpcode
+}
+
+// LastMnemonic returns the disassembly of the last instruction executed,
+// or "" if none has run since New or Reset
+func (c *Chip8) LastMnemonic() string {
+	if c.cycles == 0 {
+		return ""
+	}
+	if c.lastOpcode == 0xF000 {
+		return c.mnemonicAt(c.lastPC)
+	}
+	return DecodeOpcode(c.lastOpcode).Mnemonic
+}
+
+// InstructionStats returns how many executed instructions fell into each
+// class: system, jump, call, skip, load, arithmetic, random, draw, input
+// and misc. Classes that never ran are omitted
+func (c *Chip8) InstructionStats() map[string]uint64 {
+	stats := make(map[string]uint64)
+	for nibble, count := range c.nibbleStats {
+		if count > 0 {
+			stats[opcodeClasses[nibble]] += count
+		}
+	}
+	return stats
+}
+
+// EnableProfiling starts counting how often each instruction address
+// executes, discarding any earlier counts. Counts restart on Reset
+func (c *Chip8) EnableProfiling() {
+	c.profile = make(map[uint16]uint64)
+}
+
+// DisableProfiling stops counting executions and discards the counts
+func (c *Chip8) DisableProfiling() {
+	c.profile = nil
+}
+
+// ProfileData returns a copy of the execution count of every address run
+// since profiling was enabled, or nil if it is disabled
+func (c *Chip8) ProfileData() map[uint16]uint64 {
+	if c.profile == nil {
+		return nil
+	}
+	data := make(map[uint16]uint64, len(c.profile))
+	for addr, count := range c.profile {
+		data[addr] = count
+	}
+	return data
+}
+
+// HotAddresses returns up to n of the most executed instruction addresses,
+// busiest first, with ties in address order
+func (c *Chip8) HotAddresses(n int) []uint16 {
+	addrs := make([]uint16, 0, len(c.profile))
+	for addr := range c.profile {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		ci, cj := c.profile[addrs[i]], c.profile[addrs[j]]
+		if ci != cj {
+			return ci > cj
+		}
+		return addrs[i] < addrs[j]
+	})
+	if n < len(addrs) {
+		addrs = addrs[:max(n, 0)]
+	}
+	return addrs
+}
+
+// Halted reports whether the program has stopped by jumping to its own
+// address (1nnn with nnn equal to the instruction's address), or at a RET
+// on an empty stack under the RETHalt policy. It clears as soon as
+// anything else moves PC
+func (c *Chip8) Halted() bool {
+	return c.halted && c.PC == c.haltPC
+}
+
+// StepResult describes a single instruction executed by Step
+type StepResult struct {
+	PC       uint16 // Address the instruction was fetched from
+	Opcode   uint16 // The instruction's first word
+	Mnemonic string // Disassembly, e.g. "DRW V0, V1, 5"
+	Cost     int    // Cost from the cycle cost table; see LastCycleCost
+	Err      error  // Error returned by EmulateCycle, if any
+}
+
+// Step executes exactly one instruction, like EmulateCycle, and describes
+// what ran. Timers are not ticked
+func (c *Chip8) Step() StepResult {
+	result := StepResult{
+		PC:       c.PC,
+		Opcode:   c.readWord(c.PC),
+		Mnemonic: c.mnemonicAt(c.PC),
+	}
+	result.Err = c.EmulateCycle()
+	result.Cost = c.lastCost
+	return result
+}
@@ -0,0 +1,132 @@
+package chip8
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// runToBreak runs c until EmulateCycle reports a breakpoint, within limit
+// cycles, and returns the PC it stopped at
+func runToBreak(t *testing.T, c *Chip8, limit int) uint16 {
+	t.Helper()
+	for range limit {
+		err := c.EmulateCycle()
+		if errors.Is(err, ErrBreakpoint) {
+			return c.PC
+		}
+		if err != nil {
+			t.Fatalf("EmulateCycle at %03X: %v", c.PC, err)
+		}
+	}
+	t.Fatalf("no breakpoint within %d cycles", limit)
+	return 0
+}
+
+func TestOpcodeBreakpoints(t *testing.T) {
+	c := loadProgram(t,
+		0x6001, 0xD015, // LD V0, 1; DRW V0, V1, 5
+		0xF10A, // LD V1, K
+		0xF00A, // LD V0, K
+		0xD125, // DRW V1, V2, 5
+		0x120A,
+	)
+	c.AddOpcodeBreakpoint(0xF000, 0xD000) // Every draw
+	c.AddOpcodeBreakpoint(0xFFFF, 0xF00A) // Only LD V0, K
+
+	if pc := runToBreak(t, c, 10); pc != 0x202 {
+		t.Errorf("first break at %03X, want 202 (the first draw)", pc)
+	}
+	if c.V[0xF] != 0 || c.DrawFlag() {
+		t.Error("the draw ran before the breakpoint stopped it")
+	}
+
+	c.Quirks.WaitForKeyRelease = false
+	c.SetKey(4, true)
+	if pc := runToBreak(t, c, 10); pc != 0x206 {
+		t.Errorf("second break at %03X, want 206 (LD V0, K, not LD V1, K)", pc)
+	}
+	c.SetKey(4, false) // Key 4 was consumed by LD V1, K
+	c.SetKey(5, true)
+	if pc := runToBreak(t, c, 10); pc != 0x208 {
+		t.Errorf("third break at %03X, want 208 (the second draw)", pc)
+	}
+
+	c.RemoveOpcodeBreakpoint(0xF000, 0xD000)
+	if _, err := c.Run(20); err != nil {
+		t.Errorf("Run() = %v after removing the draw breakpoint", err)
+	}
+}
+
+func TestLastOpcode(t *testing.T) {
+	c := loadProgram(t, 0x6005, 0x2208, 0x0000, 0x0000, 0xF000, 0x0ABC, 0x00EE)
+	if pc, op := c.LastOpcode(); pc != 0 || op != 0 || c.LastMnemonic() != "" {
+		t.Errorf("before running: LastOpcode() = %03X %04X, LastMnemonic() = %q", pc, op, c.LastMnemonic())
+	}
+
+	tests := []struct {
+		pc       uint16
+		opcode   uint16
+		mnemonic string
+	}{
+		{0x200, 0x6005, "LD V0, 0x05"},
+		{0x202, 0x2208, "CALL 0x208"},
+		{0x208, 0xF000, "LD I, 0x0ABC"},
+		{0x20C, 0x00EE, "RET"},
+	}
+	for _, tt := range tests {
+		step(t, c, 1)
+		pc, op := c.LastOpcode()
+		if pc != tt.pc || op != tt.opcode || c.LastMnemonic() != tt.mnemonic {
+			t.Errorf("LastOpcode() = %03X %04X, LastMnemonic() = %q, want %03X %04X %q",
+				pc, op, c.LastMnemonic(), tt.pc, tt.opcode, tt.mnemonic)
+		}
+	}
+
+	// A breakpoint stops before executing, so nothing changes
+	c.AddBreakpoint(c.PC)
+	if err := c.EmulateCycle(); !errors.Is(err, ErrBreakpoint) {
+		t.Fatalf("EmulateCycle() = %v, want ErrBreakpoint", err)
+	}
+	if pc, _ := c.LastOpcode(); pc != 0x20C {
+		t.Errorf("LastOpcode() pc = %03X after a breakpoint, want 20C", pc)
+	}
+}
+
+func TestWatchpoints(t *testing.T) {
+	c := loadProgram(t,
+		0x6310,         // LD V3, 0x10
+		0x7300,         // ADD V3, 0 (no change)
+		0x7305,         // ADD V3, 5
+		0xA300, 0xF355, // LD I, 0x300; LD [I], V3
+	)
+	type hit struct {
+		kind     string
+		index    int
+		old, new uint8
+	}
+	var hits []hit
+	c.SetWatchCallback(func(kind string, index int, old, new uint8) {
+		hits = append(hits, hit{kind, index, old, new})
+	})
+	c.AddRegisterWatch(3)
+	c.AddMemoryWatch(0x303)
+
+	step(t, c, 5)
+	want := []hit{
+		{"register", 3, 0x00, 0x10},
+		{"register", 3, 0x10, 0x15},
+		{"memory", 0x303, 0x00, 0x15},
+	}
+	if !slices.Equal(hits, want) {
+		t.Errorf("watch hits = %v, want %v", hits, want)
+	}
+
+	hits = nil
+	c.ClearWatches()
+	c.Reset()
+	step(t, c, 5)
+	if hits != nil {
+		t.Errorf("watch hits = %v after ClearWatches, want none", hits)
+	}
+}
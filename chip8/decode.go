@@ -0,0 +1,212 @@
+package chip8
+
+// Op identifies the operation an opcode performs
+type Op uint8
+
+// Operations recognized by DecodeOpcode, named after their mnemonics
+const (
+	OpUnknown    Op = iota // Not a valid instruction
+	OpSCD                  // 00Cn - scroll down n pixels
+	OpCLS                  // 00E0 - clear the display
+	OpRET                  // 00EE - return from subroutine
+	OpSCR                  // 00FB - scroll right 4 pixels
+	OpSCL                  // 00FC - scroll left 4 pixels
+	OpLOW                  // 00FE - low-resolution mode
+	OpHIGH                 // 00FF - high-resolution mode
+	OpJP                   // 1nnn - jump to nnn
+	OpCALL                 // 2nnn - call subroutine at nnn
+	OpSEByte               // 3xkk - skip if Vx == kk
+	OpSNEByte              // 4xkk - skip if Vx != kk
+	OpSEReg                // 5xy0 - skip if Vx == Vy
+	OpLDByte               // 6xkk - Vx = kk
+	OpADDByte              // 7xkk - Vx += kk
+	OpLDReg                // 8xy0 - Vx = Vy
+	OpOR                   // 8xy1 - Vx |= Vy
+	OpAND                  // 8xy2 - Vx &= Vy
+	OpXOR                  // 8xy3 - Vx ^= Vy
+	OpADDReg               // 8xy4 - Vx += Vy with carry
+	OpSUB                  // 8xy5 - Vx -= Vy with borrow
+	OpSHR                  // 8xy6 - shift right
+	OpSUBN                 // 8xy7 - Vx = Vy - Vx with borrow
+	OpSHL                  // 8xyE - shift left
+	OpSNEReg               // 9xy0 - skip if Vx != Vy
+	OpLDI                  // Annn - I = nnn
+	OpJPV0                 // Bnnn - jump to nnn + V0
+	OpRND                  // Cxkk - Vx = random & kk
+	OpDRW                  // Dxyn - draw sprite
+	OpSKP                  // Ex9E - skip if key Vx is pressed
+	OpSKNP                 // ExA1 - skip if key Vx is not pressed
+	OpLDILong              // F000 nnnn - I = nnnn
+	OpPLANE                // Fn01 - select drawing planes
+	OpAUDIO                // F002 - load the audio pattern
+	OpLDVxDT               // Fx07 - Vx = delay timer
+	OpLDVxK                // Fx0A - wait for a key
+	OpLDDT                 // Fx15 - delay timer = Vx
+	OpLDST                 // Fx18 - sound timer = Vx
+	OpADDI                 // Fx1E - I += Vx
+	OpLDF                  // Fx29 - I = small glyph for Vx
+	OpLDHF                 // Fx30 - I = large glyph for Vx
+	OpLDB                  // Fx33 - store BCD of Vx
+	OpPITCH                // Fx3A - pitch = Vx
+	OpStore                // Fx55 - store V0-Vx at I
+	OpLoad                 // Fx65 - load V0-Vx from I
+	OpStoreFlags           // Fx75 - store V0-Vx in the RPL flags
+	OpLoadFlags            // Fx85 - load V0-Vx from the RPL flags
+)
+
+// Instruction is an opcode split into its operation and operand fields
+// Fields the operation does not use still hold the corresponding bits
+type Instruction struct {
+	Opcode   uint16
+	Op       Op
+	NNN      uint16 // Lowest 12 bits
+	N        uint8  // Lowest 4 bits
+	X        uint8  // Second nibble
+	Y        uint8  // Third nibble
+	KK       uint8  // Lowest 8 bits
+	Mnemonic string // Assembly, e.g. "DRW V0, V1, 5"; DW for unknown opcodes
+}
+
+// DecodeOpcode decodes opcode without reference to any machine state
+// The operand word of F000 nnnn is not part of the opcode, so its
+// mnemonic reads "LD I, LONG"
+func DecodeOpcode(opcode uint16) Instruction {
+	inst := decode(opcode)
+	inst.Mnemonic = inst.mnemonic()
+	return inst
+}
+
+// decode splits opcode into its fields and operation, leaving Mnemonic
+// empty so executeOpcode doesn't pay for formatting it
+func decode(opcode uint16) Instruction {
+	inst := Instruction{
+		Opcode: opcode,
+		NNN:    opcode & 0x0FFF,
+		N:      uint8(opcode & 0x000F),
+		X:      uint8((opcode & 0x0F00) >> 8),
+		Y:      uint8((opcode & 0x00F0) >> 4),
+		KK:     uint8(opcode & 0x00FF),
+	}
+	inst.Op = decodeOp(opcode, inst.X, inst.N, inst.KK)
+	return inst
+}
+
+// decodeOp selects the operation for opcode
+func decodeOp(opcode uint16, x, n, kk uint8) Op {
+	switch opcode & 0xF000 {
+	case 0x0000:
+		if opcode&0xFFF0 == 0x00C0 {
+			return OpSCD
+		}
+		switch opcode {
+		case 0x00E0:
+			return OpCLS
+		case 0x00EE:
+			return OpRET
+		case 0x00FB:
+			return OpSCR
+		case 0x00FC:
+			return OpSCL
+		case 0x00FE:
+			return OpLOW
+		case 0x00FF:
+			return OpHIGH
+		}
+
+	case 0x1000:
+		return OpJP
+	case 0x2000:
+		return OpCALL
+	case 0x3000:
+		return OpSEByte
+	case 0x4000:
+		return OpSNEByte
+	case 0x5000:
+		return OpSEReg
+	case 0x6000:
+		return OpLDByte
+	case 0x7000:
+		return OpADDByte
+
+	case 0x8000:
+		switch n {
+		case 0x0:
+			return OpLDReg
+		case 0x1:
+			return OpOR
+		case 0x2:
+			return OpAND
+		case 0x3:
+			return OpXOR
+		case 0x4:
+			return OpADDReg
+		case 0x5:
+			return OpSUB
+		case 0x6:
+			return OpSHR
+		case 0x7:
+			return OpSUBN
+		case 0xE:
+			return OpSHL
+		}
+
+	case 0x9000:
+		return OpSNEReg
+	case 0xA000:
+		return OpLDI
+	case 0xB000:
+		return OpJPV0
+	case 0xC000:
+		return OpRND
+	case 0xD000:
+		return OpDRW
+
+	case 0xE000:
+		switch kk {
+		case 0x9E:
+			return OpSKP
+		case 0xA1:
+			return OpSKNP
+		}
+
+	case 0xF000:
+		switch kk {
+		case 0x00:
+			if x == 0 {
+				return OpLDILong
+			}
+		case 0x01:
+			return OpPLANE
+		case 0x02:
+			return OpAUDIO
+		case 0x07:
+			return OpLDVxDT
+		case 0x0A:
+			return OpLDVxK
+		case 0x15:
+			return OpLDDT
+		case 0x18:
+			return OpLDST
+		case 0x1E:
+			return OpADDI
+		case 0x29:
+			return OpLDF
+		case 0x30:
+			return OpLDHF
+		case 0x33:
+			return OpLDB
+		case 0x3A:
+			return OpPITCH
+		case 0x55:
+			return OpStore
+		case 0x65:
+			return OpLoad
+		case 0x75:
+			return OpStoreFlags
+		case 0x85:
+			return OpLoadFlags
+		}
+	}
+
+	return OpUnknown
+}
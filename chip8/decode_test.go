@@ -0,0 +1,26 @@
+package chip8
+
+import "testing"
+
+func TestDecodeOpcode(t *testing.T) {
+	tests := []struct {
+		opcode uint16
+		want   Instruction
+	}{
+		{0x00E0, Instruction{Opcode: 0x00E0, Op: OpCLS, NNN: 0x0E0, KK: 0xE0, Y: 0xE, Mnemonic: "CLS"}},
+		{0x00C4, Instruction{Opcode: 0x00C4, Op: OpSCD, NNN: 0x0C4, N: 4, Y: 0xC, KK: 0xC4, Mnemonic: "SCD 4"}},
+		{0x1ABC, Instruction{Opcode: 0x1ABC, Op: OpJP, NNN: 0xABC, N: 0xC, X: 0xA, Y: 0xB, KK: 0xBC, Mnemonic: "JP 0xABC"}},
+		{0x3A42, Instruction{Opcode: 0x3A42, Op: OpSEByte, NNN: 0xA42, N: 2, X: 0xA, Y: 4, KK: 0x42, Mnemonic: "SE VA, 0x42"}},
+		{0x8125, Instruction{Opcode: 0x8125, Op: OpSUB, NNN: 0x125, N: 5, X: 1, Y: 2, KK: 0x25, Mnemonic: "SUB V1, V2"}},
+		{0xD01F, Instruction{Opcode: 0xD01F, Op: OpDRW, NNN: 0x01F, N: 0xF, X: 0, Y: 1, KK: 0x1F, Mnemonic: "DRW V0, V1, 15"}},
+		{0xF265, Instruction{Opcode: 0xF265, Op: OpLoad, NNN: 0x265, N: 5, X: 2, Y: 6, KK: 0x65, Mnemonic: "LD V2, [I]"}},
+		{0xF000, Instruction{Opcode: 0xF000, Op: OpLDILong, Mnemonic: "LD I, LONG"}},
+		{0x812F, Instruction{Opcode: 0x812F, Op: OpUnknown, NNN: 0x12F, N: 0xF, X: 1, Y: 2, KK: 0x2F, Mnemonic: "DW 0x812F"}},
+		{0xE0FF, Instruction{Opcode: 0xE0FF, Op: OpUnknown, NNN: 0x0FF, N: 0xF, Y: 0xF, KK: 0xFF, Mnemonic: "DW 0xE0FF"}},
+	}
+	for _, tt := range tests {
+		if got := DecodeOpcode(tt.opcode); got != tt.want {
+			t.Errorf("DecodeOpcode(%04X) = %+v, want %+v", tt.opcode, got, tt.want)
+		}
+	}
+}
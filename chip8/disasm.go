@@ -0,0 +1,146 @@
+package chip8
+
+import "fmt"
+
+// Disassemble decodes a ROM into one line of assembly per instruction
+// Each line is prefixed with the address the instruction would load at
+func Disassemble(rom []byte) []string {
+	lines := make([]string, 0, (len(rom)+1)/2)
+
+	for i := 0; i < len(rom); i += 2 {
+		addr := ProgramStart + i
+
+		// A trailing odd byte can't form an opcode
+		if i+1 >= len(rom) {
+			lines = append(lines, fmt.Sprintf("0x%03X: DB 0x%02X", addr, rom[i]))
+			break
+		}
+
+		opcode := uint16(rom[i])<<8 | uint16(rom[i+1])
+
+		// F000 nnnn carries its operand in the following word
+		if opcode == 0xF000 && i+3 < len(rom) {
+			long := uint16(rom[i+2])<<8 | uint16(rom[i+3])
+			lines = append(lines, fmt.Sprintf("0x%03X: LD I, 0x%04X", addr, long))
+			i += 2
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("0x%03X: %s", addr, DecodeOpcode(opcode).Mnemonic))
+	}
+
+	return lines
+}
+
+// mnemonic renders the instruction as assembly
+// Opcodes not understood by executeOpcode are rendered as DW
+func (inst Instruction) mnemonic() string {
+	x, y := inst.X, inst.Y
+
+	switch inst.Op {
+	case OpSCD:
+		return fmt.Sprintf("SCD %d", inst.N)
+	case OpCLS:
+		return "CLS"
+	case OpRET:
+		return "RET"
+	case OpSCR:
+		return "SCR"
+	case OpSCL:
+		return "SCL"
+	case OpLOW:
+		return "LOW"
+	case OpHIGH:
+		return "HIGH"
+	case OpJP:
+		return fmt.Sprintf("JP 0x%03X", inst.NNN)
+	case OpCALL:
+		return fmt.Sprintf("CALL 0x%03X", inst.NNN)
+	case OpSEByte:
+		return fmt.Sprintf("SE V%X, 0x%02X", x, inst.KK)
+	case OpSNEByte:
+		return fmt.Sprintf("SNE V%X, 0x%02X", x, inst.KK)
+	case OpSEReg:
+		return fmt.Sprintf("SE V%X, V%X", x, y)
+	case OpLDByte:
+		return fmt.Sprintf("LD V%X, 0x%02X", x, inst.KK)
+	case OpADDByte:
+		return fmt.Sprintf("ADD V%X, 0x%02X", x, inst.KK)
+	case OpLDReg:
+		return fmt.Sprintf("LD V%X, V%X", x, y)
+	case OpOR:
+		return fmt.Sprintf("OR V%X, V%X", x, y)
+	case OpAND:
+		return fmt.Sprintf("AND V%X, V%X", x, y)
+	case OpXOR:
+		return fmt.Sprintf("XOR V%X, V%X", x, y)
+	case OpADDReg:
+		return fmt.Sprintf("ADD V%X, V%X", x, y)
+	case OpSUB:
+		return fmt.Sprintf("SUB V%X, V%X", x, y)
+	case OpSHR:
+		return fmt.Sprintf("SHR V%X", x)
+	case OpSUBN:
+		return fmt.Sprintf("SUBN V%X, V%X", x, y)
+	case OpSHL:
+		return fmt.Sprintf("SHL V%X", x)
+	case OpSNEReg:
+		return fmt.Sprintf("SNE V%X, V%X", x, y)
+	case OpLDI:
+		return fmt.Sprintf("LD I, 0x%03X", inst.NNN)
+	case OpJPV0:
+		return fmt.Sprintf("JP V0, 0x%03X", inst.NNN)
+	case OpRND:
+		return fmt.Sprintf("RND V%X, 0x%02X", x, inst.KK)
+	case OpDRW:
+		return fmt.Sprintf("DRW V%X, V%X, %d", x, y, inst.N)
+	case OpSKP:
+		return fmt.Sprintf("SKP V%X", x)
+	case OpSKNP:
+		return fmt.Sprintf("SKNP V%X", x)
+	case OpLDILong:
+		return "LD I, LONG"
+	case OpPLANE:
+		return fmt.Sprintf("PLANE %d", x&0x3)
+	case OpAUDIO:
+		return "AUDIO"
+	case OpLDVxDT:
+		return fmt.Sprintf("LD V%X, DT", x)
+	case OpLDVxK:
+		return fmt.Sprintf("LD V%X, K", x)
+	case OpLDDT:
+		return fmt.Sprintf("LD DT, V%X", x)
+	case OpLDST:
+		return fmt.Sprintf("LD ST, V%X", x)
+	case OpADDI:
+		return fmt.Sprintf("ADD I, V%X", x)
+	case OpLDF:
+		return fmt.Sprintf("LD F, V%X", x)
+	case OpLDHF:
+		return fmt.Sprintf("LD HF, V%X", x)
+	case OpLDB:
+		return fmt.Sprintf("LD B, V%X", x)
+	case OpPITCH:
+		return fmt.Sprintf("PITCH V%X", x)
+	case OpStore:
+		return fmt.Sprintf("LD [I], V%X", x)
+	case OpLoad:
+		return fmt.Sprintf("LD V%X, [I]", x)
+	case OpStoreFlags:
+		return fmt.Sprintf("LD R, V%X", x)
+	case OpLoadFlags:
+		return fmt.Sprintf("LD V%X, R", x)
+	}
+
+	return fmt.Sprintf("DW 0x%04X", inst.Opcode)
+}
+
+// mnemonicAt disassembles the instruction at addr in memory, including the
+// operand word of a 4-byte F000 nnnn
+func (c *Chip8) mnemonicAt(addr uint16) string {
+	opcode := c.readWord(addr)
+	if opcode == 0xF000 {
+		return fmt.Sprintf("LD I, 0x%04X", c.readWord(addr+2))
+	}
+	return DecodeOpcode(opcode).Mnemonic
+}
@@ -0,0 +1,307 @@
+package chip8
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"math/bits"
+	"strings"
+)
+
+// GetPixel reports whether the pixel at (x, y) is set
+// Coordinates are in the active resolution
+func (c *Chip8) GetPixel(x, y int) (bool, error) {
+	width, height := c.GetDisplaySize()
+	if x < 0 || x >= width || y < 0 || y >= height {
+		return false, fmt.Errorf("pixel out of range: (%d, %d) (display %dx%d)", x, y, width, height)
+	}
+	return c.display[y*width+x] != 0, nil
+}
+
+// DisplayRows returns the display as one slice of pixels per row, e.g. 32
+// rows of 64 pixels in lores and 64 rows of 128 in hires
+func (c *Chip8) DisplayRows() [][]bool {
+	width, height := c.GetDisplaySize()
+
+	rows := make([][]bool, height)
+	for y := range rows {
+		rows[y] = make([]bool, width)
+		for x := range rows[y] {
+			rows[y][x] = c.display[y*width+x] != 0
+		}
+	}
+	return rows
+}
+
+// SetPixels returns the coordinates of every set pixel at the active
+// resolution, in row-major order
+func (c *Chip8) SetPixels() []image.Point {
+	var points []image.Point
+	c.ForEachSetPixel(func(x, y int) {
+		points = append(points, image.Point{X: x, Y: y})
+	})
+	return points
+}
+
+// ForEachSetPixel calls fn with the coordinates of every set pixel at the
+// active resolution, in row-major order
+func (c *Chip8) ForEachSetPixel(fn func(x, y int)) {
+	width, height := c.GetDisplaySize()
+	for i, pixel := range c.display[:width*height] {
+		if pixel != 0 {
+			fn(i%width, i/width)
+		}
+	}
+}
+
+// RenderImage draws the display into an RGBA image, scaling each pixel to
+// a scale x scale block of fg (set) or bg (clear). Scales below 1 are
+// treated as 1
+func (c *Chip8) RenderImage(scale int, fg, bg color.Color) *image.RGBA {
+	if scale < 1 {
+		scale = 1
+	}
+
+	width, height := c.GetDisplaySize()
+	img := image.NewRGBA(image.Rect(0, 0, width*scale, height*scale))
+
+	for y := 0; y < height*scale; y++ {
+		for x := 0; x < width*scale; x++ {
+			if c.display[(y/scale)*width+x/scale] != 0 {
+				img.Set(x, y, fg)
+			} else {
+				img.Set(x, y, bg)
+			}
+		}
+	}
+	return img
+}
+
+// GetDisplayColors draws the display into an RGBA image at the active
+// resolution, coloring each pixel with the palette entry indexed by its
+// XO-CHIP plane bits: 0 for clear, 1 for plane 1 only, 2 for plane 2 only
+// and 3 for both. Programs that never select plane 2 only use entries 0
+// and 1
+func (c *Chip8) GetDisplayColors(palette [4]color.RGBA) *image.RGBA {
+	width, height := c.GetDisplaySize()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for i, pixel := range c.display[:width*height] {
+		img.SetRGBA(i%width, i/width, palette[pixel&0x3])
+	}
+	return img
+}
+
+// SetFadeRate enables phosphor-style ghosting: each timer tick, erased
+// pixels lose rate brightness until they reach 0. A rate of 0 disables
+// fading so erased pixels go dark immediately
+func (c *Chip8) SetFadeRate(rate uint8) {
+	c.fadeRate = rate
+}
+
+// GetDisplayIntensity returns the brightness (0-255) of each pixel at the
+// active resolution. Set pixels are always 255; erased pixels fade out
+// according to SetFadeRate
+func (c *Chip8) GetDisplayIntensity() []uint8 {
+	width, height := c.GetDisplaySize()
+
+	intensity := make([]uint8, width*height)
+	for i := range intensity {
+		switch {
+		case c.display[i] != 0:
+			intensity[i] = 0xFF
+		case c.fadeRate > 0:
+			intensity[i] = c.intensity[i]
+		}
+	}
+	return intensity
+}
+
+// fadePixels dims erased pixels by one step of the fade rate
+func (c *Chip8) fadePixels() {
+	if c.fadeRate == 0 {
+		return
+	}
+
+	for i, level := range c.intensity {
+		if c.display[i] != 0 || level == 0 {
+			continue
+		}
+		if level < c.fadeRate {
+			c.intensity[i] = 0
+		} else {
+			c.intensity[i] = level - c.fadeRate
+		}
+	}
+}
+
+// DisplayHash returns a 64-bit FNV-1a hash of the display at the active
+// resolution. It depends only on pixel values, so it is stable across runs
+// and architectures and can be compared against golden frames
+func (c *Chip8) DisplayHash() uint64 {
+	width, height := c.GetDisplaySize()
+
+	h := fnv.New64a()
+	h.Write(c.display[:width*height])
+	return h.Sum64()
+}
+
+// DisplayDiff returns the indices of the pixels where GetDisplay differs
+// from other, in ascending order
+func (c *Chip8) DisplayDiff(other [ScreenWidth * ScreenHeight]uint8) []int {
+	display := c.GetDisplay()
+
+	var diff []int
+	for i := range display {
+		if display[i] != other[i] {
+			diff = append(diff, i)
+		}
+	}
+	return diff
+}
+
+// DisplayString renders the display as text, one line per row, using a
+// full block for set pixels and a space for clear ones
+func (c *Chip8) DisplayString() string {
+	return c.DisplayStringRunes('█', ' ')
+}
+
+// DisplayStringRunes renders the display as text using on for set pixels
+// and off for clear ones. Every row, including the last, ends in a newline
+func (c *Chip8) DisplayStringRunes(on, off rune) string {
+	width, height := c.GetDisplaySize()
+
+	var b strings.Builder
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if c.display[y*width+x] != 0 {
+				b.WriteRune(on)
+			} else {
+				b.WriteRune(off)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// DisplayContainsText reports whether text appears on screen drawn with
+// the active small font, e.g. a test ROM's result code. Only the font's
+// characters, the hex digits 0-9 and A-F, can be recognized; text holding
+// anything else never matches. Glyphs must be intact, on one row, and
+// separated by at most 4 blank columns
+//
+// For text drawn with a ROM's own sprites, such as "PASS", compare
+// DisplayHash with the hash of a known-good frame instead
+func (c *Chip8) DisplayContainsText(text string) bool {
+	digits := make([]uint8, 0, len(text))
+	for _, r := range strings.ToUpper(text) {
+		digit := strings.IndexRune("0123456789ABCDEF", r)
+		if digit < 0 {
+			return false
+		}
+		digits = append(digits, uint8(digit))
+	}
+	if len(digits) == 0 {
+		return false
+	}
+
+	width, height := c.GetDisplaySize()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if c.textAt(x, y, digits) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// textAt reports whether digits are drawn as font glyphs on the row at y,
+// the first one at x
+func (c *Chip8) textAt(x, y int, digits []uint8) bool {
+	if !c.glyphAt(x, y, digits[0]) {
+		return false
+	}
+	if len(digits) == 1 {
+		return true
+	}
+
+	glyphWidth := c.glyphWidth()
+	for gap := 0; gap <= 4; gap++ {
+		if c.textAt(x+glyphWidth+gap, y, digits[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// glyphAt reports whether the font glyph for digit is drawn with its top
+// left corner at (x, y)
+func (c *Chip8) glyphAt(x, y int, digit uint8) bool {
+	width, height := c.GetDisplaySize()
+	rows := len(c.font) / 16
+	glyphWidth := c.glyphWidth()
+	if x+glyphWidth > width || y+rows > height {
+		return false
+	}
+
+	glyph := c.font[int(digit)*rows:]
+	for row := 0; row < rows; row++ {
+		for col := 0; col < glyphWidth; col++ {
+			lit := glyph[row]&(0x80>>col) != 0
+			if lit != (c.display[(y+row)*width+x+col] != 0) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// glyphWidth returns the width in pixels of the active small font's glyphs,
+// from their leftmost to their rightmost used column
+func (c *Chip8) glyphWidth() int {
+	var used uint8
+	for _, b := range c.font {
+		used |= b
+	}
+	return max(8-bits.TrailingZeros8(used), 1)
+}
+
+// DirtyRegion returns the tight bounding box of pixels changed since the
+// last DrawFlag or ClearDirty, in active-resolution coordinates, so a
+// frontend can redraw only that area. dirty is false if nothing changed
+func (c *Chip8) DirtyRegion() (x, y, w, h int, dirty bool) {
+	if !c.dirty {
+		return 0, 0, 0, 0, false
+	}
+	return c.dirtyMinX, c.dirtyMinY, c.dirtyMaxX - c.dirtyMinX, c.dirtyMaxY - c.dirtyMinY, true
+}
+
+// ClearDirty resets the dirty region without consuming the draw flag
+func (c *Chip8) ClearDirty() {
+	c.dirty = false
+}
+
+// markDirty grows the dirty region to include pixel (x, y)
+func (c *Chip8) markDirty(x, y int) {
+	if !c.dirty {
+		c.dirty = true
+		c.dirtyMinX, c.dirtyMinY = x, y
+		c.dirtyMaxX, c.dirtyMaxY = x+1, y+1
+		return
+	}
+	c.dirtyMinX = min(c.dirtyMinX, x)
+	c.dirtyMinY = min(c.dirtyMinY, y)
+	c.dirtyMaxX = max(c.dirtyMaxX, x+1)
+	c.dirtyMaxY = max(c.dirtyMaxY, y+1)
+}
+
+// markAllDirty marks the whole screen as changed
+func (c *Chip8) markAllDirty() {
+	width, height := c.GetDisplaySize()
+	c.dirty = true
+	c.dirtyMinX, c.dirtyMinY = 0, 0
+	c.dirtyMaxX, c.dirtyMaxY = width, height
+}
@@ -0,0 +1,195 @@
+package chip8
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestDisplayContainsText(t *testing.T) {
+	c := loadProgram(t,
+		0x6001, 0xF029, 0x6102, 0x6203, 0xD125, // Draw 1 at (2, 3)
+		0x600A, 0xF029, 0x6107, 0xD125, // Draw A at (7, 3)
+	)
+	step(t, c, 9)
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"1", true},
+		{"A", true},
+		{"a", true},
+		{"1A", true},
+		{"A1", false},
+		{"1A0", false},
+		{"2", false},
+		{"PASS", false}, // Not hex digits
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := c.DisplayContainsText(tt.text); got != tt.want {
+			t.Errorf("DisplayContainsText(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestDisplayBufferAliasesDisplay(t *testing.T) {
+	c := loadProgram(t, 0xD015, 0x00FF) // DRW V0, V1, 5; HIGH
+	buf := c.DisplayBuffer()
+	if len(buf) != ScreenWidth*ScreenHeight || cap(buf) != len(buf) {
+		t.Fatalf("len, cap = %d, %d, want %d", len(buf), cap(buf), ScreenWidth*ScreenHeight)
+	}
+
+	step(t, c, 1)
+	if buf[0] == 0 {
+		t.Error("DisplayBuffer doesn't see a later draw")
+	}
+	step(t, c, 1)
+	if got := len(c.DisplayBuffer()); got != HiResWidth*HiResHeight {
+		t.Errorf("len = %d in high resolution, want %d", got, HiResWidth*HiResHeight)
+	}
+}
+
+func TestSetPixels(t *testing.T) {
+	c := loadProgram(t, 0x6003, 0x6104, 0xA20A, 0xD012, 0x1208, 0xA040) // Draw A0 40 at (3, 4)
+	step(t, c, 4)
+	want := []image.Point{{3, 4}, {5, 4}, {4, 5}}
+	if got := c.SetPixels(); !slices.Equal(got, want) {
+		t.Errorf("SetPixels() = %v, want %v", got, want)
+	}
+
+	var visited []image.Point
+	c.ForEachSetPixel(func(x, y int) { visited = append(visited, image.Point{x, y}) })
+	if !slices.Equal(visited, want) {
+		t.Errorf("ForEachSetPixel visited %v, want %v", visited, want)
+	}
+
+	// High-resolution coordinates go past the low-resolution screen
+	c = loadProgram(t, 0x00FF, 0x6064, 0x613C, 0xA20C, 0xD011, 0x120A, 0x8000)
+	step(t, c, 5)
+	if got := c.SetPixels(); !slices.Equal(got, []image.Point{{100, 60}}) {
+		t.Errorf("SetPixels() = %v in high resolution, want [(100,60)]", got)
+	}
+}
+
+func TestGetDisplayColors(t *testing.T) {
+	palette := [4]color.RGBA{
+		{0, 0, 0, 255},
+		{255, 0, 0, 255},
+		{0, 255, 0, 255},
+		{0, 0, 255, 255},
+	}
+	c := loadProgram(t,
+		0xA20E,         // LD I, sprite
+		0xD011,         // Plane 1: DRW V0, V1, 1 at (0, 0)
+		0xF201, 0x7001, // PLANE 2; ADD V0, 1
+		0xD011, // Plane 2 at (1, 0)
+		0x120A,
+		0x0000,
+		0xC000, // Sprite: two pixels
+	)
+	step(t, c, 5)
+
+	img := c.GetDisplayColors(palette)
+	if b := img.Bounds(); b.Dx() != ScreenWidth || b.Dy() != ScreenHeight {
+		t.Fatalf("image is %v, want %dx%d", b, ScreenWidth, ScreenHeight)
+	}
+	for x, want := range []int{1, 3, 2, 0} { // Plane 1, both, plane 2, clear
+		if got := img.RGBAAt(x, 0); got != palette[want] {
+			t.Errorf("pixel (%d, 0) = %v, want palette[%d]", x, got, want)
+		}
+	}
+	if got := img.RGBAAt(0, 1); got != palette[0] {
+		t.Errorf("pixel (0, 1) = %v, want palette[0]", got)
+	}
+}
+
+func BenchmarkGetDisplay(b *testing.B) {
+	c := NewWithSeed(1)
+	for b.Loop() {
+		display := c.GetDisplay()
+		_ = display[0]
+	}
+}
+
+func BenchmarkDisplayBuffer(b *testing.B) {
+	c := NewWithSeed(1)
+	for b.Loop() {
+		display := c.DisplayBuffer()
+		_ = display[0]
+	}
+}
+
+func TestScrollOpcodes(t *testing.T) {
+	c := loadProgram(t, 0x00C3, 0x00FB, 0x00FC, 0x00FC)
+	c.display[5*ScreenWidth+10] = 1
+	c.display[0*ScreenWidth+62] = 1 // Scrolled off by SCR
+
+	tests := []struct {
+		name string
+		want [][2]int
+	}{
+		{"SCD 3", [][2]int{{62, 3}, {10, 8}}},
+		{"SCR", [][2]int{{14, 8}}},
+		{"SCL", [][2]int{{10, 8}}},
+		{"SCL", [][2]int{{6, 8}}},
+	}
+	for _, tt := range tests {
+		step(t, c, 1)
+		var lit [][2]int
+		for i, p := range c.display[:ScreenWidth*ScreenHeight] {
+			if p != 0 {
+				lit = append(lit, [2]int{i % ScreenWidth, i / ScreenWidth})
+			}
+		}
+		if !slices.Equal(lit, tt.want) {
+			t.Errorf("%s: lit pixels = %v, want %v", tt.name, lit, tt.want)
+		}
+		if !c.DrawFlag() {
+			t.Errorf("%s: draw flag not set", tt.name)
+		}
+	}
+
+	c.scroll(0, ScreenHeight+8)
+	if c.DisplayHash() != NewWithSeed(1).DisplayHash() {
+		t.Error("scrolling down past the screen height didn't blank it")
+	}
+}
+
+func TestLargeSpriteCollision(t *testing.T) {
+	for _, hires := range []bool{true, false} {
+		mode := uint16(0x00FE) // LOW
+		if hires {
+			mode = 0x00FF // HIGH
+		}
+		program := []uint16{
+			mode,
+			0xA212,         // LD I, sprite
+			0x6000, 0x6100, // LD V0, 0; LD V1, 0
+			0xD010,         // DRW V0, V1, 0
+			0x600F, 0xD010, // LD V0, 15; DRW V0, V1, 0
+			0x120E,
+			0x0000,
+			0x8001, // Sprite: 16 pixels wide, lit at columns 0 and 15
+		}
+		c := loadProgram(t, append(program, make([]uint16, 15)...)...)
+		step(t, c, 5)
+		width, _ := c.GetDisplaySize()
+		if c.V[0xF] != 0 {
+			t.Errorf("hires=%v: VF = %d after the first draw, want 0", hires, c.V[0xF])
+		}
+		if c.display[0] == 0 || c.display[15] == 0 || c.display[width] != 0 {
+			t.Errorf("hires=%v: first row not drawn 16 pixels wide", hires)
+		}
+
+		step(t, c, 2)
+		if c.V[0xF] != 1 {
+			t.Errorf("hires=%v: VF = %d after overlapping at column 15, want 1", hires, c.V[0xF])
+		}
+		if c.display[15] != 0 || c.display[0] == 0 || c.display[30] == 0 {
+			t.Errorf("hires=%v: overlapping pixel not toggled off", hires)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package chip8
+
+import "fmt"
+
+// SUPER-CHIP large font location in memory
+const (
+	BigFontStart   = 0x050
+	BigFontsetSize = 160
+)
+
+// Large font sprites (0-F) used by Fx30, stored in memory at 0x050-0x0F0
+// Each character is 10 bytes (8x10 pixels)
+var bigFontset = [BigFontsetSize]uint8{
+	0xFF, 0xFF, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, // 0
+	0x18, 0x78, 0x78, 0x18, 0x18, 0x18, 0x18, 0x18, 0xFF, 0xFF, // 1
+	0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // 2
+	0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 3
+	0xC3, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0x03, 0x03, // 4
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 5
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 6
+	0xFF, 0xFF, 0x03, 0x03, 0x06, 0x0C, 0x18, 0x18, 0x18, 0x18, // 7
+	0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, // 8
+	0xFF, 0xFF, 0xC3, 0xC3, 0xFF, 0xFF, 0x03, 0x03, 0xFF, 0xFF, // 9
+	0x7E, 0xFF, 0xC3, 0xC3, 0xC3, 0xFF, 0xFF, 0xC3, 0xC3, 0xC3, // A
+	0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, 0xC3, 0xC3, 0xFC, 0xFC, // B
+	0x3C, 0xFF, 0xC3, 0xC0, 0xC0, 0xC0, 0xC0, 0xC3, 0xFF, 0x3C, // C
+	0xFC, 0xFE, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xC3, 0xFE, 0xFC, // D
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, // E
+	0xFF, 0xFF, 0xC0, 0xC0, 0xFF, 0xFF, 0xC0, 0xC0, 0xC0, 0xC0, // F
+}
+
+// SetFontset replaces the small font used by Fx29 with data, stored at
+// baseAddr. data holds 16 glyphs (0-F) of equal size, so Fx29 points I at
+// baseAddr + digit * len(data)/16. The font is written to memory
+// immediately and again on every Reset
+func (c *Chip8) SetFontset(data []uint8, baseAddr uint16) error {
+	if len(data) == 0 || len(data)%16 != 0 {
+		return fmt.Errorf("invalid fontset size: %d bytes (must be a multiple of 16)", len(data))
+	}
+	if int(baseAddr)+len(data) > len(c.memory) {
+		return fmt.Errorf("fontset does not fit in memory: 0x%04X+%d (memory size %d)", baseAddr, len(data), len(c.memory))
+	}
+
+	c.font = append([]uint8(nil), data...)
+	c.fontAddr = baseAddr
+	c.loadFonts()
+	return nil
+}
+
+// loadFonts writes the large font and the active small font into memory
+func (c *Chip8) loadFonts() {
+	copy(c.memory[BigFontStart:], bigFontset[:])
+	copy(c.memory[c.fontAddr:], c.font)
+}
+
+// glyphAddr returns the address of the small font glyph for digit
+func (c *Chip8) glyphAddr(digit uint8) uint16 {
+	return c.fontAddr + uint16(digit)*uint16(len(c.font)/16)
+}
@@ -0,0 +1,55 @@
+package chip8
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetFontset(t *testing.T) {
+	font := make([]uint8, 16*8) // 8-byte glyphs
+	for i := range font {
+		font[i] = uint8(i)
+	}
+
+	c := loadProgram(t, 0x600B, 0xF029, 0xF030) // LD V0, 0xB; LD F, V0; LD HF, V0
+	if err := c.SetFontset(font, 0x100); err != nil {
+		t.Fatalf("SetFontset: %v", err)
+	}
+	if c.memory[0x100+8*0xB] != 8*0xB {
+		t.Error("custom font not written to memory")
+	}
+
+	step(t, c, 2)
+	if want := uint16(0x100 + 0xB*8); c.I != want {
+		t.Errorf("Fx29 I = 0x%03X, want 0x%03X", c.I, want)
+	}
+	step(t, c, 1)
+	if want := uint16(BigFontStart + 0xB*10); c.I != want {
+		t.Errorf("Fx30 I = 0x%03X, want 0x%03X", c.I, want)
+	}
+
+	c.Reset()
+	if c.memory[0x100+8*0xB] != 8*0xB {
+		t.Error("Reset didn't restore the custom font")
+	}
+
+	tests := []struct {
+		name string
+		data []uint8
+		addr uint16
+		want string
+	}{
+		{"empty", nil, 0x100, "size"},
+		{"ragged", make([]uint8, 81), 0x100, "size"},
+		{"past memory", make([]uint8, 80), MemorySize - 79, "fit"},
+	}
+	for _, tt := range tests {
+		err := c.SetFontset(tt.data, tt.addr)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: SetFontset() = %v, want an error mentioning %q", tt.name, err, tt.want)
+		}
+	}
+	if c.glyphAddr(1) != 0x108 {
+		t.Error("a rejected SetFontset changed the font")
+	}
+}
@@ -0,0 +1,73 @@
+package chip8
+
+import "unicode"
+
+// KeyMap translates host keyboard runes to CHIP-8 keys (0x0-0xF)
+type KeyMap map[rune]uint8
+
+// DefaultKeyMap returns the conventional layout mapping the left side of a
+// QWERTY keyboard onto the COSMAC VIP hex keypad
+//
+//	1 2 3 4      1 2 3 C
+//	Q W E R  ->  4 5 6 D
+//	A S D F      7 8 9 E
+//	Z X C V      A 0 B F
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		'1': 0x1, '2': 0x2, '3': 0x3, '4': 0xC,
+		'q': 0x4, 'w': 0x5, 'e': 0x6, 'r': 0xD,
+		'a': 0x7, 's': 0x8, 'd': 0x9, 'f': 0xE,
+		'z': 0xA, 'x': 0x0, 'c': 0xB, 'v': 0xF,
+	}
+}
+
+// SetKeyMap replaces the map used by PressRune
+func (c *Chip8) SetKeyMap(m KeyMap) {
+	c.keyMap = m
+}
+
+// PressRune sets the state of the key mapped to r
+// Letters fall back to their lowercase mapping; unmapped runes are ignored
+func (c *Chip8) PressRune(r rune, pressed bool) {
+	key, ok := c.keyMap[r]
+	if !ok {
+		key, ok = c.keyMap[unicode.ToLower(r)]
+	}
+	if ok {
+		c.SetKey(key, pressed)
+	}
+}
+
+// keyEvent is a queued key press or release
+type keyEvent struct {
+	key     uint8
+	pressed bool
+}
+
+// SetInputMode selects buffered input: when enabled, events passed to
+// QueueKey are applied one per EmulateCycle, before the instruction runs,
+// so a tap shorter than a cycle is never missed. Disabling it applies any
+// still-queued events immediately
+func (c *Chip8) SetInputMode(buffered bool) {
+	c.bufferedInput = buffered
+	if !buffered {
+		for _, event := range c.keyQueue {
+			c.SetKey(event.key, event.pressed)
+		}
+		c.keyQueue = nil
+	}
+}
+
+// QueueKey records a key press or release. In buffered mode it is applied
+// at the start of a later cycle; otherwise it takes effect immediately, like
+// SetKey. Out-of-range keys are ignored
+func (c *Chip8) QueueKey(key uint8, pressed bool) {
+	if key >= 16 {
+		return
+	}
+	if !c.bufferedInput {
+		c.SetKey(key, pressed)
+		return
+	}
+	c.keyQueue = append(c.keyQueue, keyEvent{key: key, pressed: pressed})
+}
@@ -0,0 +1,189 @@
+package chip8
+
+import (
+	"fmt"
+	"slices"
+)
+
+// MemoryAccessError is returned by EmulateCycle when an instruction would
+// read or write past the end of memory. The instruction is not executed
+type MemoryAccessError struct {
+	Addr int    // First out-of-range address
+	PC   uint16 // Address of the offending instruction
+}
+
+func (e *MemoryAccessError) Error() string {
+	return fmt.Sprintf("memory access out of bounds at 0x%04X (PC 0x%03X)", e.Addr, e.PC)
+}
+
+// checkAccess verifies that the n bytes starting at addr lie in memory
+func (c *Chip8) checkAccess(addr uint16, n int) error {
+	if end := int(addr) + n; end > len(c.memory) {
+		return &MemoryAccessError{Addr: max(int(addr), len(c.memory)), PC: c.PC}
+	}
+	return nil
+}
+
+// ProtectedWriteError is returned by EmulateCycle when ProtectLowMemory is
+// set and an instruction would write below ProgramStart. The instruction
+// is not executed
+type ProtectedWriteError struct {
+	Addr uint16 // First protected address written
+	PC   uint16 // Address of the offending instruction
+}
+
+func (e *ProtectedWriteError) Error() string {
+	return fmt.Sprintf("write to protected memory at 0x%03X (PC 0x%03X)", e.Addr, e.PC)
+}
+
+// checkWrite verifies that an instruction may write the n bytes starting
+// at addr: they must lie in memory and, with ProtectLowMemory, at or above
+// ProgramStart
+func (c *Chip8) checkWrite(addr uint16, n int) error {
+	if err := c.checkAccess(addr, n); err != nil {
+		return err
+	}
+	if c.ProtectLowMemory && n > 0 && addr < ProgramStart {
+		return &ProtectedWriteError{Addr: addr, PC: c.PC}
+	}
+	return nil
+}
+
+// MemoryRegion names the part of the memory map addr falls in: "font" or
+// "big font" for the loaded fontsets, "reserved" for the rest of the
+// interpreter area below the start address, "program" from the start
+// address on, or "" past the end of memory
+func (c *Chip8) MemoryRegion(addr uint16) string {
+	switch {
+	case int(addr) >= len(c.memory):
+		return ""
+	case addr >= c.fontAddr && int(addr) < int(c.fontAddr)+len(c.font):
+		return "font"
+	case addr >= BigFontStart && addr < BigFontStart+BigFontsetSize:
+		return "big font"
+	case addr < c.startAddr:
+		return "reserved"
+	default:
+		return "program"
+	}
+}
+
+// ReadMemory returns the byte at addr
+func (c *Chip8) ReadMemory(addr uint16) (uint8, error) {
+	if int(addr) >= len(c.memory) {
+		return 0, fmt.Errorf("address out of range: 0x%04X (max 0x%03X)", addr, len(c.memory)-1)
+	}
+	return c.memory[addr], nil
+}
+
+// WriteMemory stores val at addr. Any address may be written, including
+// the fontset and interpreter area below 0x200
+func (c *Chip8) WriteMemory(addr uint16, val uint8) error {
+	if int(addr) >= len(c.memory) {
+		return fmt.Errorf("address out of range: 0x%04X (max 0x%03X)", addr, len(c.memory)-1)
+	}
+	c.memory[addr] = val
+	return nil
+}
+
+// DumpMemory returns a copy of length bytes of memory starting at start
+func (c *Chip8) DumpMemory(start, length uint16) ([]byte, error) {
+	if int(start)+int(length) > len(c.memory) {
+		return nil, fmt.Errorf("range out of bounds: 0x%04X+%d (memory size %d)", start, length, len(c.memory))
+	}
+
+	dump := make([]byte, length)
+	copy(dump, c.memory[start:])
+	return dump, nil
+}
+
+// LoadDataAt copies data into memory starting at addr, e.g. to set up a
+// sprite sheet before running. Unlike LoadROM it may target any region
+// and does not change the recorded ROM size
+func (c *Chip8) LoadDataAt(addr uint16, data []byte) error {
+	if int(addr)+len(data) > len(c.memory) {
+		return fmt.Errorf("data does not fit in memory: 0x%04X+%d (memory size %d)", addr, len(data), len(c.memory))
+	}
+
+	copy(c.memory[addr:], data)
+	return nil
+}
+
+// SpriteAt returns the sprite data a Dxyn of the given height would read
+// from addr: height bytes, or 32 (16 rows of 16 pixels) for height 0,
+// which Dxy0 draws as a SUPER-CHIP 16x16 sprite. With both XO-CHIP planes
+// selected, the second plane's data follows and is not included
+func (c *Chip8) SpriteAt(addr uint16, height uint8) ([]uint8, error) {
+	size := int(height)
+	if height == 0 {
+		size = 32
+	}
+	if int(addr)+size > len(c.memory) {
+		return nil, fmt.Errorf("sprite out of bounds: 0x%04X+%d (memory size %d)", addr, size, len(c.memory))
+	}
+
+	return append([]uint8(nil), c.memory[int(addr):int(addr)+size]...), nil
+}
+
+// CurrentSprite returns the sprite data at I, like SpriteAt
+func (c *Chip8) CurrentSprite(height uint8) ([]uint8, error) {
+	return c.SpriteAt(c.I, height)
+}
+
+// SetMemoryBanks splits memory from ProgramStart up into n banks, only one
+// of which, chosen with SwitchBank, is visible at a time. Memory below
+// ProgramStart, holding the fonts, is shared by every bank. The active
+// bank's contents become bank 0 and are copied into each of the others, so
+// a ROM loaded beforehand starts out in all of them; any other existing
+// banks are discarded. n of 1 restores the default single bank
+func (c *Chip8) SetMemoryBanks(n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid bank count: %d", n)
+	}
+	if n == 1 {
+		c.banks = nil
+		c.bank = 0
+		return nil
+	}
+
+	c.banks = make([][]uint8, n)
+	c.banks[0] = c.memory
+	for i := 1; i < n; i++ {
+		c.banks[i] = slices.Clone(c.memory)
+	}
+	c.bank = 0
+	return nil
+}
+
+// SwitchBank makes bank i visible from ProgramStart up. Programs can
+// switch banks themselves through an opcode installed with OverrideOpcode
+func (c *Chip8) SwitchBank(i int) error {
+	if i < 0 || i >= c.BankCount() {
+		return fmt.Errorf("bank out of range: %d (%d banks)", i, c.BankCount())
+	}
+	c.selectBank(i)
+	return nil
+}
+
+// ActiveBank returns the index of the visible memory bank, 0 unless
+// SetMemoryBanks was used
+func (c *Chip8) ActiveBank() int {
+	return c.bank
+}
+
+// BankCount returns the number of memory banks, 1 unless SetMemoryBanks
+// was used
+func (c *Chip8) BankCount() int {
+	return max(len(c.banks), 1)
+}
+
+// selectBank switches to bank i, carrying the shared memory below
+// ProgramStart over from the previous bank
+func (c *Chip8) selectBank(i int) {
+	if c.banks == nil || i == c.bank {
+		return
+	}
+	copy(c.banks[i][:ProgramStart], c.memory[:ProgramStart])
+	c.memory = c.banks[i]
+	c.bank = i
+}
@@ -0,0 +1,202 @@
+package chip8
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMemoryBanksAreIsolated(t *testing.T) {
+	c := loadProgram(t, 0x1200)
+	if err := c.SetMemoryBanks(2); err != nil {
+		t.Fatalf("SetMemoryBanks: %v", err)
+	}
+	c.WriteMemory(0x300, 0xAA)
+	c.WriteMemory(0x100, 0x11) // Shared by both banks
+
+	if err := c.SwitchBank(1); err != nil {
+		t.Fatalf("SwitchBank: %v", err)
+	}
+	if got, _ := c.ReadMemory(0x200); got != 0x12 {
+		t.Errorf("bank 1 ROM byte = 0x%02X; want 0x12 copied from bank 0", got)
+	}
+	if got, _ := c.ReadMemory(0x300); got != 0 {
+		t.Errorf("bank 1 sees bank 0's write: 0x%02X", got)
+	}
+	if got, _ := c.ReadMemory(0x100); got != 0x11 {
+		t.Errorf("shared byte = 0x%02X; want 0x11", got)
+	}
+	c.WriteMemory(0x300, 0xBB)
+
+	c.SwitchBank(0)
+	if got, _ := c.ReadMemory(0x300); got != 0xAA {
+		t.Errorf("bank 0 byte = 0x%02X; want 0xAA", got)
+	}
+	if err := c.SwitchBank(2); err == nil {
+		t.Error("SwitchBank(2) with 2 banks succeeded")
+	}
+}
+
+func TestMemoryBanksSurviveStateAndRewind(t *testing.T) {
+	c := loadProgram(t, 0x6060, 0x6161, 0x1204)
+	c.SetMemoryBanks(2)
+	c.EnableRewind(8)
+
+	step(t, c, 1)
+	c.SwitchBank(1)
+	c.WriteMemory(0x300, 0x61)
+	step(t, c, 1)
+
+	// Undoing the instruction run in bank 1 keeps bank 1's data
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if c.ActiveBank() != 1 {
+		t.Errorf("active bank after StepBack = %d; want 1", c.ActiveBank())
+	}
+	if got, _ := c.ReadMemory(0x300); got != 0x61 {
+		t.Errorf("bank 1 byte after StepBack = 0x%02X; want 0x61", got)
+	}
+
+	// Undoing the one run in bank 0 selects it again
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if c.ActiveBank() != 0 {
+		t.Errorf("active bank after second StepBack = %d; want 0", c.ActiveBank())
+	}
+	c.SwitchBank(1)
+	if got, _ := c.ReadMemory(0x300); got != 0 {
+		t.Errorf("bank 1 byte before it was written = 0x%02X; want 0", got)
+	}
+
+	data, err := c.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+	c.WriteMemory(0x300, 0x99)
+	c.SwitchBank(0)
+	c.WriteMemory(0x300, 0x77)
+
+	restored := New()
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("UnmarshalState: %v", err)
+	}
+	if restored.BankCount() != 2 || restored.ActiveBank() != 1 {
+		t.Fatalf("restored bank %d of %d; want 1 of 2", restored.ActiveBank(), restored.BankCount())
+	}
+	restored.SwitchBank(0)
+	if got, _ := restored.ReadMemory(0x300); got != 0 {
+		t.Errorf("restored bank 0 byte = 0x%02X; want 0", got)
+	}
+}
+
+func TestExtendedMemory(t *testing.T) {
+	rom := make([]byte, 8000)
+	copy(rom, []byte{
+		0x60, 0x5A, // LD V0, 0x5A
+		0xF0, 0x00, 0x30, 0x00, // LD I, 0x3000
+		0xF0, 0x55, // LD [I], V0
+		0xF0, 0x00, 0x1F, 0x00, // LD I, 0x1F00
+		0xF1, 0x65, // LD V1, [I]
+	})
+	rom[0x1F00-ProgramStart] = 0x77
+
+	if err := NewWithSeed(1).LoadROM(rom); err == nil {
+		t.Error("LoadROM accepted an 8000-byte ROM with standard memory")
+	}
+
+	c, err := NewWithMemory(XOMemorySize)
+	if err != nil {
+		t.Fatalf("NewWithMemory: %v", err)
+	}
+	if err := c.LoadROM(rom); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	step(t, c, 5)
+	if c.memory[0x3000] != 0x5A {
+		t.Errorf("memory[0x3000] = %02X, want 5A", c.memory[0x3000])
+	}
+	if c.V[0] != 0x77 || c.V[1] != 0x00 {
+		t.Errorf("V0, V1 = %02X, %02X, want 77, 00 read from 0x1F00", c.V[0], c.V[1])
+	}
+
+	c.I = 0xFFFF
+	if err := c.executeOpcode(0xF155); err == nil {
+		t.Error("Fx55 past the end of 64KB memory succeeded")
+	}
+}
+
+func TestSpriteAt(t *testing.T) {
+	c := NewWithSeed(1)
+	for i := range 32 {
+		c.memory[MemorySize-32+i] = uint8(i)
+	}
+
+	sprite, err := c.SpriteAt(MemorySize-5, 5)
+	if err != nil || len(sprite) != 5 || sprite[4] != 31 {
+		t.Errorf("SpriteAt(end-5, 5) = % X, %v, want the last 5 bytes", sprite, err)
+	}
+	if _, err := c.SpriteAt(MemorySize-4, 5); err == nil {
+		t.Error("SpriteAt accepted a sprite running past the end of memory")
+	}
+
+	sprite, err = c.SpriteAt(MemorySize-32, 0)
+	if err != nil || len(sprite) != 32 {
+		t.Errorf("SpriteAt(end-32, 0) = %d bytes, %v, want 32", len(sprite), err)
+	}
+	if _, err := c.SpriteAt(MemorySize-31, 0); err == nil {
+		t.Error("SpriteAt accepted a 16x16 sprite running past the end of memory")
+	}
+
+	sprite[0] = 0xFF
+	if c.memory[MemorySize-32] != 0 {
+		t.Error("SpriteAt returned memory rather than a copy")
+	}
+
+	c.I = MemorySize - 3
+	if sprite, err := c.CurrentSprite(3); err != nil || sprite[0] != 29 {
+		t.Errorf("CurrentSprite(3) = % X, %v, want 1D 1E 1F", sprite, err)
+	}
+}
+
+func TestEndOfMemoryAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		i        uint16
+		opcode   uint16
+		wantAddr int
+	}{
+		{"Fx33 at FFF", 0xFFF, 0xF033, MemorySize},
+		{"Fx33 at FFE", 0xFFE, 0xF033, MemorySize},
+		{"Fx55", 0xFFE, 0xF355, MemorySize},
+		{"Fx65", 0xFFD, 0xF465, MemorySize},
+		{"Dxyn", 0xFFC, 0xD015, MemorySize},
+		{"Dxy0", 0xFF0, 0xD010, MemorySize},
+	}
+	for _, tt := range tests {
+		c := loadProgram(t, tt.opcode)
+		c.I = tt.i
+		before := c.V
+
+		err := c.EmulateCycle()
+		var access *MemoryAccessError
+		if !errors.As(err, &access) {
+			t.Errorf("%s: EmulateCycle() = %v, want a *MemoryAccessError", tt.name, err)
+			continue
+		}
+		if access.Addr != tt.wantAddr || access.PC != 0x200 {
+			t.Errorf("%s: error at 0x%04X PC 0x%03X, want 0x%04X PC 0x200", tt.name, access.Addr, access.PC, tt.wantAddr)
+		}
+		if c.PC != 0x200 || c.V != before || c.memory[MemorySize-1] != 0 {
+			t.Errorf("%s: state changed by the failed instruction", tt.name)
+		}
+	}
+
+	// The last bytes of memory are still usable
+	c := loadProgram(t, 0x60FF, 0xF033)
+	c.I = 0xFFD
+	step(t, c, 2)
+	if got := c.memory[0xFFD:]; got[0] != 2 || got[1] != 5 || got[2] != 5 {
+		t.Errorf("BCD at FFD = % X, want 02 05 05", got)
+	}
+}
@@ -0,0 +1,94 @@
+package chip8
+
+// Quirks selects between the behaviors that differ across CHIP-8
+// implementations
+type Quirks struct {
+	// LoadStoreIncrementsI makes Fx55 and Fx65 leave I pointing past the
+	// last register transferred (I = I + x + 1), as on the COSMAC VIP
+	// SUPER-CHIP leaves I unchanged
+	LoadStoreIncrementsI bool
+
+	// ShiftUsesVy makes 8xy6 and 8xyE shift Vy into Vx, as on the COSMAC VIP
+	// When off, Vx is shifted in place and Vy is ignored
+	ShiftUsesVy bool
+
+	// ClipSprites discards sprite pixels that run off the right or bottom
+	// edge instead of wrapping them to the opposite side
+	// The starting position always wraps
+	ClipSprites bool
+
+	// WaitForKeyRelease makes Fx0A complete when a key is released, as on
+	// the COSMAC VIP, rather than as soon as any key is held
+	WaitForKeyRelease bool
+
+	// WrapPC makes the program counter wrap around to 0x000 at the end of
+	// memory instead of stopping with ErrPCOutOfBounds
+	WrapPC bool
+
+	// JumpUsesVx makes Bnnn behave as SUPER-CHIP's Bxnn, jumping to
+	// xnn + Vx instead of nnn + V0
+	JumpUsesVx bool
+
+	// LogicResetsVF makes 8xy1, 8xy2 and 8xy3 clear VF, as on the COSMAC VIP
+	// Timendus's CHIP-8 test suite expects this on for VIP accuracy
+	LogicResetsVF bool
+
+	// CollisionCountsRows makes Dxyn in high-resolution mode set VF to the
+	// number of sprite rows that collided or were clipped off the bottom
+	// of the screen, as on SUPER-CHIP, instead of 0 or 1
+	CollisionCountsRows bool
+
+	// Fx1EOverflowSetsVF makes Fx1E set VF to 1 when I + Vx exceeds 0x0FFF,
+	// as on the Amiga interpreter; Spacefight 2091! relies on it
+	// VF is left unchanged when there is no overflow
+	Fx1EOverflowSetsVF bool
+
+	// DisplayWait makes Dxyn wait for the vertical blank, as on the COSMAC
+	// VIP: after a draw, EmulateCycle does nothing until the next
+	// TickTimers, so at most one sprite is drawn per 60Hz frame. With
+	// RunFrame the draw uses up the rest of the frame's cycles
+	DisplayWait bool
+}
+
+// defaultQuirks returns the quirk settings used by New
+func defaultQuirks() Quirks {
+	return Quirks{
+		LoadStoreIncrementsI: true,
+		WaitForKeyRelease:    true,
+	}
+}
+
+// QuirksVIP returns the behavior of the original COSMAC VIP interpreter
+func QuirksVIP() Quirks {
+	return Quirks{
+		LoadStoreIncrementsI: true,
+		ShiftUsesVy:          true,
+		ClipSprites:          true,
+		WaitForKeyRelease:    true,
+		LogicResetsVF:        true,
+		DisplayWait:          true,
+	}
+}
+
+// QuirksSCHIP returns the behavior of SUPER-CHIP 1.1 on the HP48
+func QuirksSCHIP() Quirks {
+	return Quirks{
+		ClipSprites:         true,
+		JumpUsesVx:          true,
+		CollisionCountsRows: true,
+	}
+}
+
+// QuirksXOCHIP returns the behavior of XO-CHIP as implemented by Octo
+func QuirksXOCHIP() Quirks {
+	return Quirks{
+		LoadStoreIncrementsI: true,
+		ShiftUsesVy:          true,
+		WaitForKeyRelease:    true,
+	}
+}
+
+// SetQuirks replaces all quirk settings, e.g. with one of the presets
+func (c *Chip8) SetQuirks(q Quirks) {
+	c.Quirks = q
+}
@@ -0,0 +1,280 @@
+package chip8
+
+import "testing"
+
+func TestLoadStoreIncrementsI(t *testing.T) {
+	for _, increments := range []bool{true, false} {
+		c := loadProgram(t,
+			0x6011, 0x6122, 0x6233, // LD V0..V2
+			0xA300, 0xF255, // LD I, 0x300; LD [I], V2
+			0xA300, 0xF265, // LD I, 0x300; LD V2, [I]
+		)
+		c.Quirks.LoadStoreIncrementsI = increments
+		want := uint16(0x300)
+		if increments {
+			want = 0x303
+		}
+
+		step(t, c, 5)
+		if c.I != want {
+			t.Errorf("increments=%v: I = %03X after Fx55, want %03X", increments, c.I, want)
+		}
+		if got := c.memory[0x300:0x303]; got[0] != 0x11 || got[1] != 0x22 || got[2] != 0x33 {
+			t.Errorf("increments=%v: memory = % X, want 11 22 33", increments, got)
+		}
+
+		step(t, c, 2)
+		if c.I != want {
+			t.Errorf("increments=%v: I = %03X after Fx65, want %03X", increments, c.I, want)
+		}
+	}
+}
+
+func TestShiftUsesVy(t *testing.T) {
+	tests := []struct {
+		opcode      uint16
+		shiftUsesVy bool
+		wantVx      uint8
+		wantVF      uint8
+	}{
+		{0x8016, false, 0x40, 1}, // SHR V0 shifts V0 = 0x81 in place
+		{0x8016, true, 0x21, 1},  // SHR V0, V1 shifts V1 = 0x43
+		{0x801E, false, 0x02, 1}, // SHL V0
+		{0x801E, true, 0x86, 0},  // SHL V0, V1
+	}
+	for _, tt := range tests {
+		c := loadProgram(t, 0x6081, 0x6143, tt.opcode)
+		c.Quirks.ShiftUsesVy = tt.shiftUsesVy
+		step(t, c, 3)
+		if c.V[0] != tt.wantVx || c.V[0xF] != tt.wantVF {
+			t.Errorf("%04X shiftUsesVy=%v: V0 = %02X VF = %d, want %02X %d",
+				tt.opcode, tt.shiftUsesVy, c.V[0], c.V[0xF], tt.wantVx, tt.wantVF)
+		}
+		if c.V[1] != 0x43 {
+			t.Errorf("%04X shiftUsesVy=%v: V1 = %02X, want it unchanged", tt.opcode, tt.shiftUsesVy, c.V[1])
+		}
+	}
+}
+
+func TestClipSprites(t *testing.T) {
+	for _, clip := range []bool{false, true} {
+		c := loadProgram(t, 0x603E, 0xA208, 0xD011, 0x1206, 0xFF00) // Draw 0xFF at (62, 0)
+		c.Quirks.ClipSprites = clip
+		step(t, c, 3)
+
+		lit := 0
+		for _, p := range c.display[:ScreenWidth] {
+			lit += int(p)
+		}
+		wantLit, wantCol0 := 8, uint8(1)
+		if clip {
+			wantLit, wantCol0 = 2, 0
+		}
+		if lit != wantLit || c.display[0] != wantCol0 || c.display[62] != 1 || c.display[63] != 1 {
+			t.Errorf("clip=%v: %d pixels lit, column 0 = %d, want %d and %d", clip, lit, c.display[0], wantLit, wantCol0)
+		}
+	}
+}
+
+func TestWaitForKeyRelease(t *testing.T) {
+	c := loadProgram(t, 0xF00A, 0x1202) // LD V0, K; JP self
+	step(t, c, 2)
+	c.SetKey(5, true)
+	step(t, c, 2)
+	if c.PC != 0x200 {
+		t.Fatalf("PC = %03X while the key is held, want 200", c.PC)
+	}
+	c.SetKey(5, false)
+	step(t, c, 1)
+	if c.PC != 0x202 || c.V[0] != 5 {
+		t.Fatalf("PC = %03X V0 = %d after the release, want 202 5", c.PC, c.V[0])
+	}
+	step(t, c, 3)
+	if c.PC != 0x202 {
+		t.Errorf("PC = %03X, want 202: the wait completed more than once", c.PC)
+	}
+
+	c = loadProgram(t, 0xF00A, 0x1202)
+	c.Quirks.WaitForKeyRelease = false
+	step(t, c, 1)
+	c.SetKey(9, true)
+	step(t, c, 1)
+	if c.PC != 0x202 || c.V[0] != 9 {
+		t.Errorf("PC = %03X V0 = %d on press without the quirk, want 202 9", c.PC, c.V[0])
+	}
+}
+
+func TestJumpUsesVx(t *testing.T) {
+	for _, usesVx := range []bool{false, true} {
+		c := loadProgram(t, 0x6010, 0x6220, 0xB240) // LD V0, 0x10; LD V2, 0x20; JP V0, 0x240
+		c.Quirks.JumpUsesVx = usesVx
+		step(t, c, 3)
+		want := uint16(0x250) // 0x240 + V0
+		if usesVx {
+			want = 0x260 // 0x240 + V2
+		}
+		if c.PC != want {
+			t.Errorf("usesVx=%v: PC = %03X, want %03X", usesVx, c.PC, want)
+		}
+	}
+}
+
+func TestLogicResetsVF(t *testing.T) {
+	for _, opcode := range []uint16{0x8011, 0x8012, 0x8013} {
+		for _, resets := range []bool{false, true} {
+			c := loadProgram(t, 0x6F07, 0x600C, 0x610A, opcode)
+			c.Quirks.LogicResetsVF = resets
+			step(t, c, 4)
+			want := uint8(7)
+			if resets {
+				want = 0
+			}
+			if c.V[0xF] != want {
+				t.Errorf("%04X resets=%v: VF = %d, want %d", opcode, resets, c.V[0xF], want)
+			}
+		}
+	}
+}
+
+func TestClippedPixelsNeverCollide(t *testing.T) {
+	// A 4-wide row at x=62 covers columns 62, 63 and, when wrapping, 0, 1
+	// Column 0 is already lit
+	for _, clip := range []bool{false, true} {
+		c := loadProgram(t, 0x603E, 0xA208, 0xD011, 0x1206, 0xF000)
+		c.Quirks.ClipSprites = clip
+		c.display[0] = 1
+		step(t, c, 3)
+
+		want := uint8(1)
+		if clip {
+			want = 0
+		}
+		if c.V[0xF] != want {
+			t.Errorf("clip=%v: VF = %d, want %d", clip, c.V[0xF], want)
+		}
+		if c.display[0] != 1-want {
+			t.Errorf("clip=%v: column 0 = %d, want %d", clip, c.display[0], 1-want)
+		}
+	}
+}
+
+func TestQuirksPresets(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Quirks
+		want Quirks
+	}{
+		{"VIP", QuirksVIP(), Quirks{
+			LoadStoreIncrementsI: true,
+			ShiftUsesVy:          true,
+			ClipSprites:          true,
+			WaitForKeyRelease:    true,
+			LogicResetsVF:        true,
+			DisplayWait:          true,
+		}},
+		{"SCHIP", QuirksSCHIP(), Quirks{
+			ClipSprites:         true,
+			JumpUsesVx:          true,
+			CollisionCountsRows: true,
+		}},
+		{"XOCHIP", QuirksXOCHIP(), Quirks{
+			LoadStoreIncrementsI: true,
+			ShiftUsesVy:          true,
+			WaitForKeyRelease:    true,
+		}},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("Quirks%s() = %+v, want %+v", tt.name, tt.got, tt.want)
+		}
+
+		c := NewWithSeed(1)
+		c.SetQuirks(tt.got)
+		if c.Quirks != tt.want {
+			t.Errorf("SetQuirks(Quirks%s()) left %+v", tt.name, c.Quirks)
+		}
+	}
+}
+
+func TestCollisionCountsRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       uint16 // HIGH or LOW
+		countsRows bool
+		y          uint8
+		want       uint8
+	}{
+		{"hires rows", 0x00FF, true, 0, 3},
+		{"hires rows clipped", 0x00FF, true, 62, 3}, // 2 collided, 1 clipped
+		{"hires 0/1", 0x00FF, false, 0, 1},
+		{"lores", 0x00FE, true, 0, 1},
+	}
+	for _, tt := range tests {
+		c := loadProgram(t,
+			tt.mode,
+			0x6100|uint16(tt.y), // LD V1, y
+			0xA20E,              // LD I, sprite
+			0xD013, 0xD013,      // Draw the same 3 rows twice
+			0x120A,
+			0x0000,
+			0x8080, 0x8000,
+		)
+		c.Quirks.CollisionCountsRows = tt.countsRows
+		c.Quirks.ClipSprites = true
+		step(t, c, 5)
+		if c.V[0xF] != tt.want {
+			t.Errorf("%s: VF = %d, want %d", tt.name, c.V[0xF], tt.want)
+		}
+	}
+}
+
+func TestFx1EOverflowSetsVF(t *testing.T) {
+	for _, setsVF := range []bool{false, true} {
+		c := loadProgram(t, 0x6F07, 0x6020, 0xAFF0, 0xF01E) // VF = 7; I = 0xFF0 + 0x20
+		c.Quirks.Fx1EOverflowSetsVF = setsVF
+		step(t, c, 4)
+		want := uint8(7)
+		if setsVF {
+			want = 1
+		}
+		if c.V[0xF] != want || c.I != 0x1010 {
+			t.Errorf("setsVF=%v: VF = %d I = %04X, want %d 1010", setsVF, c.V[0xF], c.I, want)
+		}
+
+		// No overflow leaves VF alone
+		c = loadProgram(t, 0x6F07, 0x6020, 0xAF00, 0xF01E)
+		c.Quirks.Fx1EOverflowSetsVF = setsVF
+		step(t, c, 4)
+		if c.V[0xF] != 7 {
+			t.Errorf("setsVF=%v: VF = %d without overflow, want 7", setsVF, c.V[0xF])
+		}
+	}
+}
+
+func TestHeldKeyConsumedByFx0A(t *testing.T) {
+	c := loadProgram(t,
+		0xF00A,         // LD V0, K
+		0xE09E, 0x6101, // SKP V0; LD V1, 1
+		0xE09E, 0x6201, // SKP V0; LD V2, 1
+		0x120A,
+	)
+	c.Quirks.WaitForKeyRelease = false
+	step(t, c, 1)
+	c.SetKey(3, true)
+	step(t, c, 1)
+	if c.V[0] != 3 || c.PC != 0x202 {
+		t.Fatalf("V0 = %d PC = %03X, want 3 202 after the press", c.V[0], c.PC)
+	}
+
+	step(t, c, 2) // The key is still held from the wait
+	if c.V[1] != 1 {
+		t.Error("Ex9E skipped on the key Fx0A consumed")
+	}
+
+	c.SetKey(3, false)
+	c.SetKey(3, true)
+	step(t, c, 1)
+	if c.PC != 0x20A {
+		t.Errorf("PC = %03X, want 20A: a fresh press should skip", c.PC)
+	}
+}
@@ -0,0 +1,128 @@
+package chip8
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Recording is a sequence of key events, each stamped with the number of
+// instructions executed since recording started
+type Recording struct {
+	events []recordedKey
+}
+
+type recordedKey struct {
+	cycle   uint64
+	key     uint8
+	pressed bool
+}
+
+// Recording format: magic "C8RC", a version byte, a little-endian uint32
+// event count, then per event a uint64 cycle, the key and the pressed flag
+const (
+	recordingMagic     = "C8RC"
+	recordingVersion   = 1
+	recordingHeader    = len(recordingMagic) + 1 + 4
+	recordingEventSize = 8 + 1 + 1
+)
+
+// Len returns the number of recorded key events
+func (r *Recording) Len() int {
+	return len(r.events)
+}
+
+// MarshalBinary encodes the recording into bytes for saving
+func (r *Recording) MarshalBinary() ([]byte, error) {
+	buf := make([]byte, 0, recordingHeader+len(r.events)*recordingEventSize)
+	buf = append(buf, recordingMagic...)
+	buf = append(buf, recordingVersion)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(r.events)))
+	for _, e := range r.events {
+		buf = binary.LittleEndian.AppendUint64(buf, e.cycle)
+		buf = append(buf, e.key, boolByte(e.pressed))
+	}
+	return buf, nil
+}
+
+// UnmarshalBinary restores a recording produced by MarshalBinary
+func (r *Recording) UnmarshalBinary(data []byte) error {
+	if len(data) < recordingHeader {
+		return fmt.Errorf("recording too short: %d bytes", len(data))
+	}
+	if string(data[:len(recordingMagic)]) != recordingMagic {
+		return fmt.Errorf("invalid recording magic: %q", data[:len(recordingMagic)])
+	}
+	if version := data[len(recordingMagic)]; version != recordingVersion {
+		return fmt.Errorf("unsupported recording version: %d", version)
+	}
+	count := int(binary.LittleEndian.Uint32(data[len(recordingMagic)+1:]))
+	if size := recordingHeader + count*recordingEventSize; len(data) != size {
+		return fmt.Errorf("recording has wrong size: %d bytes (want %d)", len(data), size)
+	}
+
+	events := make([]recordedKey, count)
+	off := recordingHeader
+	for i := range events {
+		events[i] = recordedKey{
+			cycle:   binary.LittleEndian.Uint64(data[off:]),
+			key:     data[off+8],
+			pressed: data[off+9] != 0,
+		}
+		off += recordingEventSize
+	}
+	r.events = events
+	return nil
+}
+
+// StartRecording begins capturing every key change made through SetKey,
+// replacing any recording in progress, and returns the recording it fills
+func (c *Chip8) StartRecording() *Recording {
+	c.recording = &Recording{}
+	c.recordStart = c.cycles
+	return c.recording
+}
+
+// StopRecording stops capturing key changes and returns the recording,
+// or nil if none was in progress
+func (c *Chip8) StopRecording() *Recording {
+	r := c.recording
+	c.recording = nil
+	return r
+}
+
+// PlayRecording replays r from the current cycle: each recorded key change
+// is applied by EmulateCycle once as many instructions have executed as
+// when it was captured. For a pixel-identical replay, start from the state
+// and RNG seed that recording started from. Pass nil to stop playback
+func (c *Chip8) PlayRecording(r *Recording) {
+	c.playback = r
+	c.playbackStart = c.cycles
+	c.playbackNext = 0
+}
+
+// Playing reports whether a recording is being replayed and has events left
+func (c *Chip8) Playing() bool {
+	return c.playback != nil && c.playbackNext < len(c.playback.events)
+}
+
+// recordKey captures a key change made at the current cycle
+func (c *Chip8) recordKey(key uint8, pressed bool) {
+	c.recording.events = append(c.recording.events, recordedKey{
+		cycle:   c.cycles - c.recordStart,
+		key:     key,
+		pressed: pressed,
+	})
+}
+
+// applyPlayback applies every recorded key change due by the current cycle
+func (c *Chip8) applyPlayback() {
+	events := c.playback.events
+	for c.playbackNext < len(events) && events[c.playbackNext].cycle <= c.cycles-c.playbackStart {
+		e := events[c.playbackNext]
+		c.playbackNext++
+		c.SetKey(e.key, e.pressed)
+	}
+	if c.playbackNext >= len(events) {
+		c.playback = nil
+	}
+}
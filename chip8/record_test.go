@@ -0,0 +1,64 @@
+package chip8
+
+import "testing"
+
+// keyProgram draws the glyph of each key released at a random column
+var keyProgram = []uint16{
+	0xF10A, // LD V1, K
+	0xC23F, // RND V2, 0x3F
+	0xF129, // LD F, V1
+	0xD235, // DRW V2, V3, 5
+	0x7304, // ADD V3, 4
+	0x1200, // JP 0x200
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	const cycles = 400
+
+	live := loadProgram(t, keyProgram...)
+	step(t, live, 3)
+	rec := live.StartRecording()
+	start := live.CycleCount()
+	for i := range cycles {
+		switch i % 20 {
+		case 5:
+			live.SetKey(uint8(i/20%16), true)
+		case 10:
+			live.SetKey(uint8(i/20%16), false)
+		}
+		step(t, live, 1)
+	}
+	if live.StopRecording() != rec || rec.Len() != 2*cycles/20 {
+		t.Fatalf("recorded %d events, want %d", rec.Len(), 2*cycles/20)
+	}
+
+	data, err := rec.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	var loaded Recording
+	if err := loaded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+
+	replay := loadProgram(t, keyProgram...)
+	step(t, replay, 3)
+	replay.PlayRecording(&loaded)
+	step(t, replay, cycles)
+	if replay.Playing() {
+		t.Error("Playing() = true with every event applied")
+	}
+	if replay.CycleCount()-3 != live.CycleCount()-start {
+		t.Fatalf("replay ran %d cycles, want %d", replay.CycleCount()-3, live.CycleCount()-start)
+	}
+	if live.V[3] != 4*cycles/20 {
+		t.Fatalf("V3 = %d, want %d: not every key release was seen", live.V[3], 4*cycles/20)
+	}
+	if replay.DisplayHash() != live.DisplayHash() || replay.V != live.V {
+		t.Error("replay diverged from the live run")
+	}
+
+	if err := loaded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("UnmarshalBinary accepted a truncated recording")
+	}
+}
@@ -0,0 +1,107 @@
+package chip8
+
+// Renderer receives the emulator's drawing operations, so a frontend can
+// draw straight into its own surface instead of reading the display
+// Coordinates are in the active resolution, which Resize announces
+// Planes are XO-CHIP bitplane masks: 1 for plane 1, 2 for plane 2, and for
+// Clear and Scroll 3 for both. Programs that never select planes use only
+// plane 1
+//
+// While a custom renderer is set the internal display buffer is not
+// updated, so GetDisplay, DisplayHash, save states and the other display
+// readers see the picture as it was when the renderer was installed
+type Renderer interface {
+	// SetPixel toggles the pixel at (x, y) in plane, a single plane bit,
+	// for one lit sprite bit. It reports whether the pixel was lit in that
+	// plane, which becomes the collision flag in VF. With both planes
+	// selected it is called once for each
+	SetPixel(x, y int, plane uint8) (collided bool)
+
+	// Clear turns every pixel off in the planes set in planes
+	Clear(planes uint8)
+
+	// Scroll shifts the planes set in planes by (dx, dy) pixels, turning
+	// off the pixels shifted in and leaving other planes in place
+	Scroll(dx, dy int, planes uint8)
+
+	// Resize switches to a width x height resolution with every pixel off
+	Resize(width, height int)
+}
+
+// SetRenderer makes the emulator draw through r. Pass nil to go back to
+// the internal display buffer. An emulator created with NewHeadless always
+// draws into the internal display buffer and ignores r
+func (c *Chip8) SetRenderer(r Renderer) {
+	if r == nil || c.headless {
+		r = displayRenderer{c}
+	}
+	c.renderer = r
+}
+
+// customRenderer reports whether drawing goes to a renderer set with
+// SetRenderer rather than the internal display buffer
+func (c *Chip8) customRenderer() bool {
+	_, ok := c.renderer.(displayRenderer)
+	return !ok
+}
+
+// displayRenderer is the default Renderer, drawing into the emulator's
+// display buffer, one bit per plane in each pixel
+type displayRenderer struct {
+	c *Chip8
+}
+
+func (r displayRenderer) SetPixel(x, y int, plane uint8) bool {
+	c := r.c
+	width, _ := c.GetDisplaySize()
+	i := y*width + x
+
+	collided := c.display[i]&plane != 0
+	c.display[i] ^= plane
+	if c.display[i] != 0 {
+		c.intensity[i] = 0xFF
+	}
+	return collided
+}
+
+func (r displayRenderer) Clear(planes uint8) {
+	c := r.c
+	width, _ := c.GetDisplaySize()
+
+	for i := range c.display {
+		if c.display[i]&planes != 0 {
+			c.markDirty(i%width, i/width)
+			c.display[i] &^= planes
+		}
+	}
+}
+
+func (r displayRenderer) Scroll(dx, dy int, planes uint8) {
+	c := r.c
+	width, height := c.GetDisplaySize()
+
+	scrolled := c.display
+	var faded [HiResWidth * HiResHeight]uint8
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var pixel uint8
+			srcX, srcY := x-dx, y-dy
+			if srcX >= 0 && srcX < width && srcY >= 0 && srcY < height {
+				pixel = c.display[srcY*width+srcX]
+				faded[y*width+x] = c.intensity[srcY*width+srcX]
+			}
+			scrolled[y*width+x] = scrolled[y*width+x]&^planes | pixel&planes
+			if scrolled[y*width+x] != c.display[y*width+x] {
+				c.markDirty(x, y)
+			}
+		}
+	}
+
+	c.display = scrolled
+	c.intensity = faded
+}
+
+func (r displayRenderer) Resize(width, height int) {
+	r.c.display = [HiResWidth * HiResHeight]uint8{}
+	r.c.intensity = [HiResWidth * HiResHeight]uint8{}
+}
@@ -0,0 +1,83 @@
+package chip8
+
+import "testing"
+
+// planeRenderer is a Renderer keeping each XO-CHIP plane separately and
+// logging the plane masks it is given
+type planeRenderer struct {
+	pixels   map[[3]int]bool // x, y, plane
+	setCalls []uint8
+	clears   []uint8
+	scrolls  []uint8
+}
+
+func (r *planeRenderer) SetPixel(x, y int, plane uint8) bool {
+	r.setCalls = append(r.setCalls, plane)
+	key := [3]int{x, y, int(plane)}
+	collided := r.pixels[key]
+	r.pixels[key] = !collided
+	return collided
+}
+
+func (r *planeRenderer) Clear(planes uint8) {
+	r.clears = append(r.clears, planes)
+	for key := range r.pixels {
+		if uint8(key[2])&planes != 0 {
+			delete(r.pixels, key)
+		}
+	}
+}
+
+func (r *planeRenderer) Scroll(dx, dy int, planes uint8) {
+	r.scrolls = append(r.scrolls, planes)
+}
+
+func (r *planeRenderer) Resize(width, height int) {
+	clear(r.pixels)
+}
+
+func TestRendererReceivesPlanes(t *testing.T) {
+	program := []uint16{
+		0xF301, // PLANE 3
+		0xA20E, // LD I, sprite
+		0xD011, // DRW V0, V1, 1
+		0xF101, // PLANE 1
+		0x00E0, // CLS
+		0x00C1, // SCD 1
+		0x120C, // JP self
+		0x8080, // Sprite: one pixel in each plane
+	}
+
+	c := loadProgram(t, program...)
+	r := &planeRenderer{pixels: map[[3]int]bool{}}
+	c.SetRenderer(r)
+
+	step(t, c, 3)
+	if len(r.setCalls) != 2 || r.setCalls[0] != 1 || r.setCalls[1] != 2 {
+		t.Fatalf("SetPixel planes = %v, want [1 2]", r.setCalls)
+	}
+	if !r.pixels[[3]int{0, 0, 1}] || !r.pixels[[3]int{0, 0, 2}] {
+		t.Errorf("pixels = %v, want (0, 0) lit in both planes", r.pixels)
+	}
+	if c.V[0xF] != 0 {
+		t.Errorf("VF = %d, want 0 for a draw onto a blank screen", c.V[0xF])
+	}
+
+	step(t, c, 3)
+	if len(r.clears) != 1 || r.clears[0] != 1 {
+		t.Errorf("Clear planes = %v, want [1]", r.clears)
+	}
+	if r.pixels[[3]int{0, 0, 1}] || !r.pixels[[3]int{0, 0, 2}] {
+		t.Errorf("pixels = %v after clearing plane 1, want only plane 2 lit", r.pixels)
+	}
+	if len(r.scrolls) != 1 || r.scrolls[0] != 1 {
+		t.Errorf("Scroll planes = %v, want [1]", r.scrolls)
+	}
+
+	// The internal display buffer gets the same result
+	d := loadProgram(t, program...)
+	step(t, d, 3)
+	if d.display[0] != 3 {
+		t.Errorf("display[0] = %d, want 3 with both planes lit", d.display[0])
+	}
+}
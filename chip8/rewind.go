@@ -0,0 +1,104 @@
+package chip8
+
+import "errors"
+
+// ErrNoRewindHistory is returned by StepBack when there is no earlier
+// state to return to
+var ErrNoRewindHistory = errors.New("no rewind history")
+
+// rewindEntry is a save state plus the bookkeeping a save state doesn't
+// carry: cycle count, last instruction, RET halt and playback position
+type rewindEntry struct {
+	state        []byte
+	cycles       uint64
+	lastPC       uint16
+	lastOpcode   uint16
+	lastCost     int
+	halted       bool
+	haltPC       uint16
+	playbackNext int
+}
+
+// rewindBuffer is a fixed-capacity ring of rewind entries, newest last
+type rewindBuffer struct {
+	states []rewindEntry
+	next   int // Slot the next state is written to
+	count  int
+}
+
+// push stores entry, overwriting the oldest once the buffer is full
+func (b *rewindBuffer) push(entry rewindEntry) {
+	b.states[b.next] = entry
+	b.next = (b.next + 1) % len(b.states)
+	if b.count < len(b.states) {
+		b.count++
+	}
+}
+
+// pop removes and returns the newest entry
+func (b *rewindBuffer) pop() (rewindEntry, bool) {
+	if b.count == 0 {
+		return rewindEntry{}, false
+	}
+	b.next = (b.next - 1 + len(b.states)) % len(b.states)
+	b.count--
+
+	entry := b.states[b.next]
+	b.states[b.next] = rewindEntry{}
+	return entry, true
+}
+
+// EnableRewind keeps the state from before each of the last frames
+// instructions so they can be undone with StepBack. Passing 0 disables
+// rewinding and frees the history
+//
+// Each entry is a full save state of roughly 8KB plus the memory size
+// (about 12KB for standard CHIP-8, 72KB with XO-CHIP memory), so 1000
+// frames of standard history costs around 12MB. With SetMemoryBanks every
+// bank is saved, adding its size above ProgramStart per entry
+func (c *Chip8) EnableRewind(frames int) {
+	if frames <= 0 {
+		c.rewind = nil
+		return
+	}
+	c.rewind = &rewindBuffer{states: make([]rewindEntry, frames)}
+}
+
+// captureRewind returns the rewind entry for the machine as it is now
+func (c *Chip8) captureRewind() rewindEntry {
+	state, _ := c.MarshalState()
+	return rewindEntry{
+		state:        state,
+		cycles:       c.cycles,
+		lastPC:       c.lastPC,
+		lastOpcode:   c.lastOpcode,
+		lastCost:     c.lastCost,
+		halted:       c.halted,
+		haltPC:       c.haltPC,
+		playbackNext: c.playbackNext,
+	}
+}
+
+// StepBack undoes the most recently executed instruction by restoring the
+// state captured before it ran, including CycleCount, LastOpcode and
+// any Fx0A or DisplayWait wait in progress
+func (c *Chip8) StepBack() error {
+	if c.rewind == nil {
+		return errors.New("rewind is not enabled")
+	}
+
+	entry, ok := c.rewind.pop()
+	if !ok {
+		return ErrNoRewindHistory
+	}
+	if err := c.UnmarshalState(entry.state); err != nil {
+		return err
+	}
+
+	c.cycles = entry.cycles
+	c.lastPC, c.lastOpcode = entry.lastPC, entry.lastOpcode
+	c.lastCost = entry.lastCost
+	c.halted, c.haltPC = entry.halted, entry.haltPC
+	c.playbackNext = entry.playbackNext
+	return nil
+}
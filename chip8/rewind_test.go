@@ -0,0 +1,80 @@
+package chip8
+
+import "testing"
+
+func TestStepBackRestoresBookkeeping(t *testing.T) {
+	c := loadProgram(t, 0x6001, 0x1202) // LD V0, 1; JP 0x202 (halts)
+	c.EnableRewind(8)
+	step(t, c, 2)
+	if !c.Halted() {
+		t.Fatal("Halted() = false after the self-jump")
+	}
+
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if got := c.CycleCount(); got != 1 {
+		t.Errorf("CycleCount() = %d, want 1", got)
+	}
+	if pc, op := c.LastOpcode(); pc != 0x200 || op != 0x6001 {
+		t.Errorf("LastOpcode() = %03X %04X, want 200 6001", pc, op)
+	}
+	if c.Halted() {
+		t.Error("Halted() = true after stepping back over the self-jump")
+	}
+	if c.PC != 0x202 || c.V[0] != 1 {
+		t.Errorf("PC = %03X V0 = %d, want 202 1", c.PC, c.V[0])
+	}
+
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if got := c.CycleCount(); got != 0 {
+		t.Errorf("CycleCount() = %d, want 0", got)
+	}
+	if err := c.StepBack(); err != ErrNoRewindHistory {
+		t.Errorf("StepBack() = %v, want ErrNoRewindHistory", err)
+	}
+}
+
+func TestStepBackRestoresKeyWait(t *testing.T) {
+	c := loadProgram(t, 0xF00A, 0x6101) // LD V0, K; LD V1, 1
+	c.EnableRewind(8)
+	step(t, c, 1)
+	c.SetKey(7, true)
+	step(t, c, 1)
+	c.SetKey(7, false)
+	step(t, c, 1)
+	if c.PC != 0x202 || c.V[0] != 7 {
+		t.Fatalf("PC = %03X V0 = %d, want 202 7 after the key release", c.PC, c.V[0])
+	}
+
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if c.PC != 0x200 || c.CycleCount() != 2 {
+		t.Fatalf("PC = %03X CycleCount() = %d, want 200 2", c.PC, c.CycleCount())
+	}
+	step(t, c, 1)
+	if c.PC != 0x202 || c.V[0] != 7 {
+		t.Errorf("PC = %03X V0 = %d, want 202 7 replaying the release", c.PC, c.V[0])
+	}
+}
+
+func TestStepBackReplaysRandom(t *testing.T) {
+	c := loadProgram(t, 0xC0FF, 0xC1FF) // RND V0, 0xFF; RND V1, 0xFF
+	c.EnableRewind(8)
+	step(t, c, 2)
+	v0, v1 := c.V[0], c.V[1]
+
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	if err := c.StepBack(); err != nil {
+		t.Fatalf("StepBack: %v", err)
+	}
+	step(t, c, 2)
+	if c.V[0] != v0 || c.V[1] != v1 {
+		t.Errorf("V0, V1 = %02X, %02X after rewinding, want %02X, %02X", c.V[0], c.V[1], v0, v1)
+	}
+}
@@ -0,0 +1,88 @@
+package chip8
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadROMReader reads a ROM from r until EOF and loads it like LoadROM
+// At most one byte more than fits in memory is read, so oversized input
+// is rejected without buffering all of it
+func (c *Chip8) LoadROMReader(r io.Reader) error {
+	rom, err := io.ReadAll(io.LimitReader(r, int64(len(c.memory)-int(c.startAddr)+1)))
+	if err != nil {
+		return fmt.Errorf("reading ROM: %w", err)
+	}
+	return c.LoadROM(rom)
+}
+
+// LoadROMFile loads the ROM stored at path
+func (c *Chip8) LoadROMFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening ROM: %w", err)
+	}
+	defer f.Close()
+
+	return c.LoadROMReader(f)
+}
+
+// LoadHex parses a hex listing and loads it like LoadROM. Tokens are
+// separated by whitespace and may have a 0x prefix; each holds one or more
+// whole bytes, so "60 05" and "0x6005" are equivalent. Text from // to the
+// end of a line is ignored
+func (c *Chip8) LoadHex(text string) error {
+	var rom []byte
+	for lineNum, line := range strings.Split(text, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		for col := 0; col < len(line); {
+			if isHexSpace(line[col]) {
+				col++
+				continue
+			}
+			end := col
+			for end < len(line) && !isHexSpace(line[end]) {
+				end++
+			}
+
+			token := line[col:end]
+			digits := strings.TrimPrefix(strings.TrimPrefix(token, "0x"), "0X")
+			data, err := hex.DecodeString(digits)
+			if err != nil || len(data) == 0 {
+				return fmt.Errorf("line %d, column %d: invalid hex token %q", lineNum+1, col+1, token)
+			}
+			rom = append(rom, data...)
+			col = end
+		}
+	}
+	return c.LoadROM(rom)
+}
+
+// isHexSpace reports whether b separates tokens in a hex listing
+func isHexSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r'
+}
+
+// ROMSize returns the length in bytes of the last ROM loaded
+func (c *Chip8) ROMSize() int {
+	return c.romSize
+}
+
+// LastLoadWarnings returns the non-fatal problems found in the last ROM
+// loaded, such as an odd byte count; nil if there were none
+func (c *Chip8) LastLoadWarnings() []string {
+	return c.loadWarnings
+}
+
+// ROMRange returns the address range occupied by the loaded ROM: start is
+// the start address (0x200 by default) and end is one past the last ROM byte
+// They are ints so that end can be 65536 for a ROM filling XO-CHIP memory
+func (c *Chip8) ROMRange() (start, end int) {
+	return int(c.startAddr), int(c.startAddr) + c.romSize
+}
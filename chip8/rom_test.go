@@ -0,0 +1,99 @@
+package chip8
+
+import "testing"
+
+func TestROMRange(t *testing.T) {
+	c := NewWithSeed(1)
+	if err := c.LoadROM([]byte{0x00, 0xE0, 0x12, 0x00}); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	if start, end := c.ROMRange(); start != 0x200 || end != 0x204 {
+		t.Errorf("ROMRange() = %#x, %#x, want 0x200, 0x204", start, end)
+	}
+
+	xo, err := NewWithMemory(XOMemorySize)
+	if err != nil {
+		t.Fatalf("NewWithMemory: %v", err)
+	}
+	if err := xo.LoadROM(make([]byte, XOMemorySize-ProgramStart)); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	if start, end := xo.ROMRange(); start != ProgramStart || end != XOMemorySize {
+		t.Errorf("ROMRange() = %#x, %#x for a full ROM, want %#x, %#x", start, end, ProgramStart, XOMemorySize)
+	}
+}
+
+func TestStartAddress(t *testing.T) {
+	c := NewWithSeed(1)
+	if err := c.SetStartAddress(0x600); err != nil {
+		t.Fatalf("SetStartAddress: %v", err)
+	}
+	if err := c.LoadROM([]byte{0x60, 0x42, 0x16, 0x02}); err != nil { // LD V0, 0x42; JP self
+		t.Fatalf("LoadROM: %v", err)
+	}
+	if c.memory[0x600] != 0x60 || c.memory[0x200] != 0 {
+		t.Error("ROM not loaded at 0x600")
+	}
+	step(t, c, 1)
+	if c.V[0] != 0x42 || c.PC != 0x602 {
+		t.Errorf("V0 = %02X PC = %03X, want 42 602", c.V[0], c.PC)
+	}
+
+	c.Reset()
+	if c.PC != 0x600 {
+		t.Errorf("PC = %03X after Reset, want 600", c.PC)
+	}
+
+	if err := c.LoadROM(make([]byte, MemorySize-0x600+1)); err == nil {
+		t.Error("LoadROM accepted a ROM running past the end of memory from 0x600")
+	}
+	if err := c.SetStartAddress(MemorySize - 1); err == nil {
+		t.Error("SetStartAddress accepted an address with no room for an instruction")
+	}
+}
+
+func TestOddLengthROMWarns(t *testing.T) {
+	c := NewWithSeed(1)
+	if err := c.LoadROM([]byte{0x60, 0x01, 0x12}); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	if warnings := c.LastLoadWarnings(); len(warnings) != 1 {
+		t.Errorf("LastLoadWarnings() = %q, want one odd-length warning", warnings)
+	}
+	if c.ROMSize() != 3 || c.memory[0x202] != 0x12 {
+		t.Error("odd-length ROM not loaded")
+	}
+
+	if err := c.LoadROM([]byte{0x60, 0x01}); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	if warnings := c.LastLoadWarnings(); warnings != nil {
+		t.Errorf("LastLoadWarnings() = %q for an even ROM, want nil", warnings)
+	}
+}
+
+func TestLoadHex(t *testing.T) {
+	c := NewWithSeed(1)
+	listing := `// Load and loop
+0x60 2A   // LD V0, 0x2A
+0x1202    // JP self
+`
+	if err := c.LoadHex(listing); err != nil {
+		t.Fatalf("LoadHex: %v", err)
+	}
+	if c.ROMSize() != 4 {
+		t.Errorf("ROMSize() = %d, want 4", c.ROMSize())
+	}
+	step(t, c, 1)
+	if c.V[0] != 0x2A {
+		t.Errorf("V0 = %02X, want 2A", c.V[0])
+	}
+
+	err := c.LoadHex("60 2A\n  12 0G\n")
+	if err == nil || err.Error() != `line 2, column 6: invalid hex token "0G"` {
+		t.Errorf("LoadHex() = %v, want an error at line 2, column 6", err)
+	}
+	if err := c.LoadHex("602"); err == nil {
+		t.Error("LoadHex accepted a token with an odd number of digits")
+	}
+}
@@ -0,0 +1,42 @@
+package chip8
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sync"
+)
+
+// knownROMs maps the hex SHA-1 of a ROM image to the quirk profile it needs
+// It ships unseeded: entries (starting with the Timendus test suite) are a
+// follow-up and must be added as "sha1": QuirksVIP() etc, computing the
+// hash with ROMHash on the exact bytes of a verified dump. Until then
+// DetectQuirks only matches ROMs added with RegisterROMQuirks
+var knownROMs = map[string]Quirks{}
+
+// knownROMsMu guards knownROMs, so RegisterROMQuirks is safe to call while
+// other goroutines run DetectQuirks
+var knownROMsMu sync.RWMutex
+
+// ROMHash returns the hex SHA-1 of rom, the key used by DetectQuirks
+func ROMHash(rom []byte) string {
+	sum := sha1.Sum(rom)
+	return hex.EncodeToString(sum[:])
+}
+
+// DetectQuirks looks rom up in the table of known ROMs, returning the
+// recommended quirk profile and whether a match was found
+func DetectQuirks(rom []byte) (Quirks, bool) {
+	hash := ROMHash(rom)
+	knownROMsMu.RLock()
+	defer knownROMsMu.RUnlock()
+	q, ok := knownROMs[hash]
+	return q, ok
+}
+
+// RegisterROMQuirks adds or replaces the quirk profile for the ROM whose
+// hex SHA-1 (as returned by ROMHash) is hash
+func RegisterROMQuirks(hash string, q Quirks) {
+	knownROMsMu.Lock()
+	defer knownROMsMu.Unlock()
+	knownROMs[hash] = q
+}
@@ -0,0 +1,55 @@
+package chip8
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestDetectQuirks(t *testing.T) {
+	rom := []byte{0x00, 0xE0, 0x12, 0x02}
+	if _, ok := DetectQuirks(rom); ok {
+		t.Fatal("DetectQuirks matched an unregistered ROM")
+	}
+
+	hash := ROMHash(rom)
+	if hash != ROMHash(append([]byte(nil), rom...)) {
+		t.Fatal("ROMHash is not deterministic")
+	}
+	RegisterROMQuirks(hash, QuirksSCHIP())
+	t.Cleanup(func() {
+		knownROMsMu.Lock()
+		delete(knownROMs, hash)
+		knownROMsMu.Unlock()
+	})
+
+	q, ok := DetectQuirks(rom)
+	if !ok || q != QuirksSCHIP() {
+		t.Errorf("DetectQuirks() = %+v, %v, want the SUPER-CHIP profile", q, ok)
+	}
+	if _, ok := DetectQuirks(rom[:2]); ok {
+		t.Error("DetectQuirks matched a truncated ROM")
+	}
+}
+
+func TestDetectQuirksConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := range 8 {
+		rom := []byte{0xA2, byte(i)}
+		hash := ROMHash(rom)
+		t.Cleanup(func() {
+			knownROMsMu.Lock()
+			delete(knownROMs, hash)
+			knownROMsMu.Unlock()
+		})
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterROMQuirks(hash, QuirksVIP())
+		}()
+		go func() {
+			defer wg.Done()
+			DetectQuirks(rom)
+		}()
+	}
+	wg.Wait()
+}
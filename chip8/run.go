@@ -0,0 +1,137 @@
+package chip8
+
+import "errors"
+
+// DefaultCyclesPerFrame is a typical CPU speed for RunFrame: 10 cycles per
+// frame at 60 frames per second is 600 instructions per second. Most
+// CHIP-8 programs run well between 8 and 12
+const DefaultCyclesPerFrame = 10
+
+// RunFrame runs one 60Hz frame and should be called 60 times per second
+// It executes cyclesPerFrame instructions, ticks the timers once, then
+// calls the draw callback once if the screen changed during the frame and
+// the sound handler if the beep started or stopped. Notifications are not
+// made per instruction while the frame runs. Under the DisplayWait quirk
+// the cycles left after a sprite is drawn do nothing
+// If a cycle fails the remaining cycles and the timer tick are skipped,
+// but notifications for what already ran are still delivered
+// While paused it does nothing
+func (c *Chip8) RunFrame(cyclesPerFrame int) error {
+	if c.paused {
+		return nil
+	}
+
+	wasActive := c.SoundActive()
+	c.inFrame = true
+	c.frameDrawn = false
+
+	var err error
+	for i := 0; i < cyclesPerFrame; i++ {
+		if err = c.EmulateCycle(); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		c.TickTimers()
+	}
+
+	c.inFrame = false
+	if c.frameDrawn && c.drawCallback != nil {
+		c.drawCallback(c.GetDisplay())
+	}
+	if active := c.SoundActive(); active != wasActive && c.soundHandler != nil {
+		c.soundHandler(active)
+	}
+
+	return err
+}
+
+// Run executes up to maxCycles instructions, returning how many ran
+// It stops early without error when the program halts (see Halted), and
+// with the cycle's error on a breakpoint or any other failure. Nothing
+// runs while paused
+func (c *Chip8) Run(maxCycles int) (int, error) {
+	return c.run(maxCycles, func() bool { return c.Halted() })
+}
+
+// RunUntil executes instructions until PC reaches pc, the program halts,
+// or maxCycles instructions have run. It returns the number executed;
+// callers can compare ProgramCounter with pc to tell which limit was hit
+func (c *Chip8) RunUntil(pc uint16, maxCycles int) (int, error) {
+	return c.run(maxCycles, func() bool { return c.PC == pc || c.Halted() })
+}
+
+// RunUntilDraw executes instructions until one changes the display, the
+// program halts, or maxCycles instructions have run, returning the number
+// executed. The instruction that drew is included in the count. A draw
+// flag already set when it is called does not stop it. Timers are not
+// ticked; the caller remains responsible for calling TickTimers at 60Hz
+func (c *Chip8) RunUntilDraw(maxCycles int) (int, error) {
+	start := c.drawCount
+	return c.run(maxCycles, func() bool { return c.drawCount != start || c.Halted() })
+}
+
+// ErrCycleBudgetExceeded is returned by Run, RunUntil and RunUntilDraw
+// when a call would execute more instructions than the cycle budget
+var ErrCycleBudgetExceeded = errors.New("cycle budget exceeded")
+
+// SetCycleBudget caps how many instructions a single Run, RunUntil or
+// RunUntilDraw call may execute, whatever maxCycles is passed, guarding
+// servers against ROMs that never stop. A call that reaches the budget
+// returns ErrCycleBudgetExceeded. The count restarts with every call
+// Zero, the default, means no budget
+func (c *Chip8) SetCycleBudget(n uint64) {
+	c.cycleBudget = n
+}
+
+// run executes instructions until stop reports true before a cycle, an
+// error occurs, or maxCycles instructions have run
+func (c *Chip8) run(maxCycles int, stop func() bool) (int, error) {
+	if c.paused {
+		return 0, nil
+	}
+
+	n := 0
+	for ; n < maxCycles; n++ {
+		if stop() {
+			return n, nil
+		}
+		if c.cycleBudget != 0 && uint64(n) >= c.cycleBudget {
+			return n, ErrCycleBudgetExceeded
+		}
+		if err := c.EmulateCycle(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Pause freezes the CPU and timers: EmulateCycle, TickTimers, RunFrame and
+// Run do nothing until Resume. If the beep is playing, the sound handler
+// is told it stopped
+func (c *Chip8) Pause() {
+	if c.paused {
+		return
+	}
+	c.paused = true
+	if c.soundTimer > 0 && c.soundHandler != nil {
+		c.soundHandler(false)
+	}
+}
+
+// Resume continues execution after Pause, restarting the beep if the sound
+// timer is still running
+func (c *Chip8) Resume() {
+	if !c.paused {
+		return
+	}
+	c.paused = false
+	if c.soundTimer > 0 && c.soundHandler != nil {
+		c.soundHandler(true)
+	}
+}
+
+// IsPaused reports whether the emulator is paused
+func (c *Chip8) IsPaused() bool {
+	return c.paused
+}
@@ -0,0 +1,80 @@
+package chip8
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRunFrame(t *testing.T) {
+	c := loadProgram(t,
+		0x6064, 0xF015, // LD V0, 100; LD DT, V0
+		0x6105, 0xF118, // LD V1, 5; LD ST, V1
+		0xD015, // DRW V0, V1, 5
+		0x120A, // JP self
+	)
+	draws := 0
+	c.SetDrawCallback(func([ScreenWidth * ScreenHeight]uint8) { draws++ })
+	var sound []bool
+	c.SetSoundHandler(func(playing bool) { sound = append(sound, playing) })
+
+	for frame := 1; frame <= 6; frame++ {
+		if err := c.RunFrame(DefaultCyclesPerFrame); err != nil {
+			t.Fatalf("frame %d: %v", frame, err)
+		}
+		if got, want := c.DelayTimer(), uint8(100-frame); got != want {
+			t.Errorf("frame %d: DelayTimer() = %d, want %d", frame, got, want)
+		}
+	}
+	if draws != 1 {
+		t.Errorf("draw callback called %d times, want once for the frame that drew", draws)
+	}
+	if !slices.Equal(sound, []bool{true, false}) {
+		t.Errorf("sound handler calls = %v, want [true false]", sound)
+	}
+}
+
+func TestRunUntilDraw(t *testing.T) {
+	c := loadProgram(t, 0x6001, 0x6102, 0x6203, 0xD015, 0x6004, 0x120A)
+	n, err := c.RunUntilDraw(100)
+	if err != nil {
+		t.Fatalf("RunUntilDraw: %v", err)
+	}
+	if n != 4 || c.PC != 0x208 {
+		t.Errorf("ran %d cycles to PC %03X, want 4 stopping after the draw at 206", n, c.PC)
+	}
+
+	// No further draws: stops when the program halts
+	n, err = c.RunUntilDraw(100)
+	if err != nil || n != 2 || !c.Halted() {
+		t.Errorf("RunUntilDraw() = %d, %v, Halted() = %v, want 2, nil, true", n, err, c.Halted())
+	}
+
+	c = loadProgram(t, 0x7001, 0x1200) // Never draws or halts
+	if n, err := c.RunUntilDraw(50); err != nil || n != 50 {
+		t.Errorf("RunUntilDraw(50) = %d, %v, want 50, nil", n, err)
+	}
+}
+
+func TestCycleBudget(t *testing.T) {
+	c := loadProgram(t, 0x7001, 0x1200) // Loops forever without halting
+	c.SetCycleBudget(500)
+
+	n, err := c.Run(1_000_000)
+	if !errors.Is(err, ErrCycleBudgetExceeded) || n != 500 {
+		t.Errorf("Run() = %d, %v, want 500, ErrCycleBudgetExceeded", n, err)
+	}
+
+	// The budget restarts with every call
+	if n, err := c.RunUntil(0xFFE, 1_000_000); !errors.Is(err, ErrCycleBudgetExceeded) || n != 500 {
+		t.Errorf("RunUntil() = %d, %v, want 500, ErrCycleBudgetExceeded", n, err)
+	}
+	if n, err := c.Run(100); err != nil || n != 100 {
+		t.Errorf("Run(100) = %d, %v under the budget, want 100, nil", n, err)
+	}
+
+	c.SetCycleBudget(0)
+	if n, err := c.Run(2000); err != nil || n != 2000 {
+		t.Errorf("Run(2000) = %d, %v with no budget, want 2000, nil", n, err)
+	}
+}
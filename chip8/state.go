@@ -0,0 +1,289 @@
+package chip8
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Save-state format
+//
+// All multi-byte values are little-endian. Fields are stored in order:
+//
+//	size  field
+//	4     magic "C8ST"
+//	1     version
+//	4     memory size in bytes (n)
+//	n     memory
+//	16    V0-VF
+//	2     I
+//	2     PC
+//	1     SP
+//	32    stack (16 x uint16)
+//	1     delay timer
+//	1     sound timer
+//	8192  display (128x64 buffer)
+//	16    keys (0 or 1 each)
+//	1     draw flag (0 or 1)
+//	1     high-resolution mode (0 or 1)
+//	1     selected XO-CHIP planes
+//	16    XO-CHIP audio pattern
+//	1     XO-CHIP pitch
+//	8     SUPER-CHIP RPL user flags
+//	2     start address
+//	4     loaded ROM size
+//	2     small font address
+//	1     waiting for an Fx0A key release (0 or 1)
+//	16    keys seen down during the Fx0A wait (0 or 1 each)
+//	16    keys consumed by Fx0A (0 or 1 each)
+//	1     waiting for vertical blank (0 or 1)
+//	1     Cxkk random source saved (0 or 1)
+//	8     Cxkk random source state, 0 unless saved
+//	2     small font size in bytes (f)
+//	f     small font
+//	4     memory bank count (b), 1 unless SetMemoryBanks enabled banking
+//	4     active memory bank
+//	...   memory from ProgramStart up of each inactive bank, in order
+//	      ((b-1) x (n-ProgramStart) bytes)
+const (
+	stateMagic   = "C8ST"
+	stateVersion = 1
+	stateHeader  = len(stateMagic) + 1
+
+	// Size of the fields between memory and the small font, up to and
+	// including the font size
+	stateBodySize = 8344
+
+	// Offset of the start address from the end of memory
+	stateLayoutOffset = 8291
+)
+
+// MarshalState serializes the complete emulator state into a versioned
+// binary snapshot that can be restored later
+func (c *Chip8) MarshalState() ([]byte, error) {
+	buf := make([]byte, 0, stateHeader+4+len(c.memory)+stateBodySize+len(c.font))
+
+	buf = append(buf, stateMagic...)
+	buf = append(buf, stateVersion)
+
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(c.memory)))
+	buf = append(buf, c.memory...)
+	buf = append(buf, c.V[:]...)
+	buf = binary.LittleEndian.AppendUint16(buf, c.I)
+	buf = binary.LittleEndian.AppendUint16(buf, c.PC)
+	buf = append(buf, c.SP)
+	for _, addr := range c.stack {
+		buf = binary.LittleEndian.AppendUint16(buf, addr)
+	}
+	buf = append(buf, c.delayTimer, c.soundTimer)
+	buf = append(buf, c.display[:]...)
+	for _, pressed := range c.keys {
+		buf = append(buf, boolByte(pressed))
+	}
+	buf = append(buf, boolByte(c.drawFlag))
+	buf = append(buf, boolByte(c.hires))
+	buf = append(buf, c.planes)
+	buf = append(buf, c.audioPattern[:]...)
+	buf = append(buf, c.pitch)
+	buf = append(buf, c.rplFlags[:]...)
+	buf = binary.LittleEndian.AppendUint16(buf, c.startAddr)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(c.romSize))
+	buf = binary.LittleEndian.AppendUint16(buf, c.fontAddr)
+	buf = append(buf, boolByte(c.waitingForKey))
+	for _, down := range c.waitKeysDown {
+		buf = append(buf, boolByte(down))
+	}
+	for _, consumed := range c.consumedKeys {
+		buf = append(buf, boolByte(consumed))
+	}
+	buf = append(buf, boolByte(c.waitingForVBlank))
+	var rngState uint64
+	if c.seeded != nil {
+		rngState = c.seeded.state
+	}
+	buf = append(buf, boolByte(c.seeded != nil))
+	buf = binary.LittleEndian.AppendUint64(buf, rngState)
+	buf = binary.LittleEndian.AppendUint16(buf, uint16(len(c.font)))
+	buf = append(buf, c.font...)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(c.BankCount()))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(c.bank))
+	for i, bank := range c.banks {
+		if i != c.bank {
+			buf = append(buf, bank[ProgramStart:]...)
+		}
+	}
+
+	return buf, nil
+}
+
+// UnmarshalState restores the emulator state from a snapshot produced by
+// MarshalState, replacing memory, registers, timers, display, keys, the
+// start address and font, and any pending Fx0A or display wait. The Cxkk
+// random sequence is restored too, unless either machine used a source
+// set with SetRandSource, which can't be saved
+func (c *Chip8) UnmarshalState(data []byte) error {
+	if len(data) < stateHeader {
+		return fmt.Errorf("state too short: %d bytes", len(data))
+	}
+	if string(data[:len(stateMagic)]) != stateMagic {
+		return fmt.Errorf("invalid state magic: %q", data[:len(stateMagic)])
+	}
+	if version := data[len(stateMagic)]; version != stateVersion {
+		return fmt.Errorf("unsupported state version: %d", version)
+	}
+	if len(data) < stateHeader+4 {
+		return fmt.Errorf("state too short: %d bytes", len(data))
+	}
+	memorySize := int(binary.LittleEndian.Uint32(data[stateHeader:]))
+	if memorySize <= ProgramStart || memorySize > XOMemorySize {
+		return fmt.Errorf("invalid state memory size: %d bytes", memorySize)
+	}
+
+	r := stateReader{buf: data, off: stateHeader + 4}
+	size := r.off + memorySize + stateBodySize
+	fontSize := 0
+	if len(data) >= size {
+		fontSize = int(binary.LittleEndian.Uint16(data[size-2:]))
+		size += fontSize
+	}
+	banks, bank := 1, 0
+	if len(data) >= size+8 {
+		banks = int(binary.LittleEndian.Uint32(data[size:]))
+		bank = int(binary.LittleEndian.Uint32(data[size+4:]))
+		if banks < 1 || bank >= banks {
+			return fmt.Errorf("invalid state memory bank: %d of %d", bank, banks)
+		}
+	}
+	size += 8 + (banks-1)*(memorySize-ProgramStart)
+	if len(data) != size {
+		return fmt.Errorf("state has wrong size: %d bytes (want %d)", len(data), size)
+	}
+	if sp := data[r.off+memorySize+RegisterCount+4]; sp > StackSize {
+		return fmt.Errorf("invalid state stack pointer: %d (stack size %d)", sp, StackSize)
+	}
+	tail := r.off + memorySize + stateLayoutOffset
+	if err := validateStateLayout(data[tail:], memorySize, fontSize); err != nil {
+		return err
+	}
+
+	if len(c.memory) != memorySize {
+		c.memory = make([]uint8, memorySize)
+	}
+	r.read(c.memory)
+	r.read(c.V[:])
+	c.I = r.uint16()
+	c.PC = r.uint16()
+	c.SP = r.uint8()
+	for i := range c.stack {
+		c.stack[i] = r.uint16()
+	}
+	c.delayTimer = r.uint8()
+	c.soundTimer = r.uint8()
+	r.read(c.display[:])
+	for i := range c.keys {
+		c.keys[i] = r.uint8() != 0
+	}
+	c.drawFlag = r.uint8() != 0
+	c.hires = r.uint8() != 0
+	c.planes = r.uint8()
+	r.read(c.audioPattern[:])
+	c.pitch = r.uint8()
+	r.read(c.rplFlags[:])
+	c.startAddr = r.uint16()
+	c.romSize = int(r.uint32())
+	c.fontAddr = r.uint16()
+	c.waitingForKey = r.uint8() != 0
+	for i := range c.waitKeysDown {
+		c.waitKeysDown[i] = r.uint8() != 0
+	}
+	for i := range c.consumedKeys {
+		c.consumedKeys[i] = r.uint8() != 0
+	}
+	c.waitingForVBlank = r.uint8() != 0
+	rngSaved := r.uint8() != 0
+	rngState := r.uint64()
+	if rngSaved && c.seeded != nil {
+		c.seeded.state = rngState
+	}
+	c.font = make([]uint8, r.uint16())
+	r.read(c.font)
+
+	// The active bank was restored as memory; the others follow the font
+	c.banks, c.bank = nil, 0
+	r.off += 8
+	if banks > 1 {
+		c.banks = make([][]uint8, banks)
+		for i := range c.banks {
+			if i == bank {
+				c.banks[i] = c.memory
+				continue
+			}
+			c.banks[i] = make([]uint8, memorySize)
+			copy(c.banks[i], c.memory[:ProgramStart])
+			r.read(c.banks[i][ProgramStart:])
+		}
+		c.bank = bank
+	}
+
+	return nil
+}
+
+// validateStateLayout checks the memory layout fields of a snapshot,
+// starting at the start address, before anything is restored
+func validateStateLayout(tail []byte, memorySize, fontSize int) error {
+	startAddr := int(binary.LittleEndian.Uint16(tail))
+	romSize := int(binary.LittleEndian.Uint32(tail[2:]))
+	fontAddr := int(binary.LittleEndian.Uint16(tail[6:]))
+
+	switch {
+	case startAddr+2 > memorySize:
+		return fmt.Errorf("invalid state start address: 0x%04X", startAddr)
+	case startAddr+romSize > memorySize:
+		return fmt.Errorf("invalid state ROM size: %d bytes", romSize)
+	case fontSize == 0 || fontSize%16 != 0 || fontAddr+fontSize > memorySize:
+		return fmt.Errorf("invalid state font: %d bytes at 0x%04X", fontSize, fontAddr)
+	}
+	return nil
+}
+
+// stateReader reads sequential fields from a snapshot whose length has
+// already been validated
+type stateReader struct {
+	buf []byte
+	off int
+}
+
+func (r *stateReader) read(dst []uint8) {
+	r.off += copy(dst, r.buf[r.off:])
+}
+
+func (r *stateReader) uint8() uint8 {
+	v := r.buf[r.off]
+	r.off++
+	return v
+}
+
+func (r *stateReader) uint16() uint16 {
+	v := binary.LittleEndian.Uint16(r.buf[r.off:])
+	r.off += 2
+	return v
+}
+
+func (r *stateReader) uint32() uint32 {
+	v := binary.LittleEndian.Uint32(r.buf[r.off:])
+	r.off += 4
+	return v
+}
+
+func (r *stateReader) uint64() uint64 {
+	v := binary.LittleEndian.Uint64(r.buf[r.off:])
+	r.off += 8
+	return v
+}
+
+// boolByte encodes a bool as a single byte
+func boolByte(b bool) uint8 {
+	if b {
+		return 1
+	}
+	return 0
+}
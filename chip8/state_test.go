@@ -0,0 +1,246 @@
+package chip8
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// observed holds everything the exported getters report about a machine
+type observed struct {
+	Registers   [RegisterCount]uint8
+	Index, PC   uint16
+	SP          uint8
+	Stack       [StackSize]uint16
+	Delay       uint8
+	Sound       uint8
+	SoundActive bool
+	Memory      []byte
+	Display     []uint8
+	Width       int
+	Height      int
+	HiRes       bool
+	Planes      uint8
+	Keys        [16]bool
+	Pattern     [16]uint8
+	Pitch       uint8
+	RPLFlags    [8]uint8
+	DrawFlag    bool
+	ROMSize     int
+	ROMRange    [2]int
+	Regions     [3]string
+}
+
+func observe(t *testing.T, c *Chip8) observed {
+	t.Helper()
+	memory, err := c.DumpMemory(0, MemorySize)
+	if err != nil {
+		t.Fatalf("DumpMemory: %v", err)
+	}
+	start, end := c.ROMRange()
+
+	return observed{
+		Registers:   c.Registers(),
+		Index:       c.Index(),
+		PC:          c.ProgramCounter(),
+		SP:          c.StackPointer(),
+		Stack:       c.Stack(),
+		Delay:       c.DelayTimer(),
+		Sound:       c.SoundTimer(),
+		SoundActive: c.SoundActive(),
+		Memory:      memory,
+		Display:     append([]uint8(nil), c.DisplayBuffer()...),
+		Width:       c.Width(),
+		Height:      c.Height(),
+		HiRes:       c.HiRes(),
+		Planes:      c.Planes(),
+		Keys:        c.KeyState(),
+		Pattern:     c.AudioPattern(),
+		Pitch:       c.AudioPitch(),
+		RPLFlags:    c.RPLFlags(),
+		DrawFlag:    c.PeekDrawFlag(),
+		ROMSize:     c.ROMSize(),
+		ROMRange:    [2]int{start, end},
+		Regions:     [3]string{c.MemoryRegion(0x100), c.MemoryRegion(0x5FF), c.MemoryRegion(0x600)},
+	}
+}
+
+// busyMachine returns a machine whose state differs from New's in every
+// serialized field, stopped in an Fx0A wait with key 4 held
+func busyMachine(t *testing.T) *Chip8 {
+	t.Helper()
+	c := NewWithSeed(1)
+	font := make([]uint8, 16*6)
+	for i := range font {
+		font[i] = uint8(i) | 0x80
+	}
+	if err := c.SetFontset(font, 0x100); err != nil {
+		t.Fatalf("SetFontset: %v", err)
+	}
+	if err := c.SetStartAddress(0x600); err != nil {
+		t.Fatalf("SetStartAddress: %v", err)
+	}
+	rom := []byte{
+		0x00, 0xFF, // HIGH
+		0xF3, 0x01, // PLANE 3
+		0x60, 0x05, // LD V0, 5
+		0x61, 0x07, // LD V1, 7
+		0xF0, 0x29, // LD F, V0
+		0xD0, 0x13, // DRW V0, V1, 3
+		0xF0, 0x15, // LD DT, V0
+		0xF1, 0x18, // LD ST, V1
+		0xF1, 0x3A, // PITCH V1
+		0xF1, 0x75, // LD R, V1
+		0x26, 0x18, // CALL 0x618
+		0x00, 0x00,
+		0xF2, 0x0A, // 0x618: LD V2, K
+		0x00, 0xEE, // RET
+	}
+	if err := c.LoadROM(rom); err != nil {
+		t.Fatalf("LoadROM: %v", err)
+	}
+	c.SetKey(4, true)
+	step(t, c, 12)
+	return c
+}
+
+func TestStateRoundTrip(t *testing.T) {
+	c := busyMachine(t)
+	want := observe(t, c)
+
+	data, err := c.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+
+	// Mutate the original, so nothing can leak from it into the restore
+	c.SetKey(4, false)
+	step(t, c, 2)
+	c.ResetFull()
+
+	restored := New()
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("UnmarshalState: %v", err)
+	}
+	if got := observe(t, restored); !reflect.DeepEqual(got, want) {
+		t.Errorf("restored state differs:\n got %+v\nwant %+v", got, want)
+	}
+
+	// The Fx0A wait resumes: the key held when saving completes it on release
+	restored.SetKey(4, false)
+	step(t, restored, 1)
+	if restored.V[2] != 4 || restored.PC != 0x61A {
+		t.Errorf("after release V2 = %d, PC = 0x%03X; want 4, 0x61A", restored.V[2], restored.PC)
+	}
+
+	// Fx29 uses the restored font
+	restored.V[0] = 2
+	restored.executeOpcode(0xF029)
+	if restored.I != 0x100+2*6 {
+		t.Errorf("Fx29 I = 0x%03X; want 0x10C", restored.I)
+	}
+}
+
+func TestStateRestoresConsumedKey(t *testing.T) {
+	c := loadProgram(t, 0xF00A, 0xE09E, 0x1204, 0x1206)
+	c.Quirks.WaitForKeyRelease = false
+	c.SetKey(7, true)
+	step(t, c, 1) // The wait completes on the held key, consuming it
+
+	data, err := c.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+	restored := New()
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("UnmarshalState: %v", err)
+	}
+
+	step(t, restored, 1)
+	if restored.PC != 0x204 {
+		t.Errorf("SKP on a consumed key moved PC to 0x%03X; want 0x204", restored.PC)
+	}
+}
+
+func TestStateRestoresDisplayWait(t *testing.T) {
+	c := loadProgram(t, 0xD001, 0x6001)
+	c.Quirks.DisplayWait = true
+	step(t, c, 1)
+
+	data, err := c.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+	restored := New()
+	restored.Quirks.DisplayWait = true
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("UnmarshalState: %v", err)
+	}
+
+	step(t, restored, 1)
+	if restored.V[0] != 0 {
+		t.Error("executed an instruction before the vertical blank")
+	}
+	restored.TickTimers()
+	step(t, restored, 1)
+	if restored.V[0] != 1 {
+		t.Error("did not resume after the vertical blank")
+	}
+}
+
+func TestUnmarshalStateRejectsBadInput(t *testing.T) {
+	data, err := busyMachine(t).MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+	corrupt := func(edit func(b []byte) []byte) []byte {
+		return edit(append([]byte(nil), data...))
+	}
+	fontOff := len(data) - 8 - 16*6 // The font is followed by the bank fields
+	spOff := stateHeader + 4 + MemorySize + RegisterCount + 4
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "too short"},
+		{"magic", corrupt(func(b []byte) []byte { b[0] = 'X'; return b }), "magic"},
+		{"version", corrupt(func(b []byte) []byte { b[4] = 99; return b }), "version"},
+		{"truncated", data[:len(data)-1], "wrong size"},
+		{"trailing", append(append([]byte(nil), data...), 0), "wrong size"},
+		{"font size", corrupt(func(b []byte) []byte {
+			b[fontOff-2], b[fontOff-1] = 0, 0
+			return append(b[:fontOff], b[fontOff+16*6:]...)
+		}), "font"},
+		{"stack pointer", corrupt(func(b []byte) []byte { b[spOff] = 200; return b }), "stack pointer"},
+		{"bank", corrupt(func(b []byte) []byte { b[len(b)-4] = 1; return b }), "bank"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New().UnmarshalState(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("UnmarshalState error = %v; want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStateRestoresRandomSequence(t *testing.T) {
+	c := loadProgram(t, 0xC0FF, 0xC1FF, 0xC2FF)
+	step(t, c, 1)
+	data, err := c.MarshalState()
+	if err != nil {
+		t.Fatalf("MarshalState: %v", err)
+	}
+	step(t, c, 2)
+
+	restored := NewWithSeed(99)
+	if err := restored.UnmarshalState(data); err != nil {
+		t.Fatalf("UnmarshalState: %v", err)
+	}
+	step(t, restored, 2)
+	if restored.V[1] != c.V[1] || restored.V[2] != c.V[2] {
+		t.Errorf("V1, V2 = %02X, %02X after restoring, want %02X, %02X", restored.V[1], restored.V[2], c.V[1], c.V[2])
+	}
+}
@@ -0,0 +1,110 @@
+package chip8
+
+import "sync"
+
+// SyncChip8 guards a Chip8 with a read-write mutex so that emulation and
+// rendering can run on different goroutines
+// Methods that change state take the write lock; accessors take the read
+// lock. Use Do for anything not wrapped here
+type SyncChip8 struct {
+	mu sync.RWMutex
+	c  *Chip8
+}
+
+// NewSync wraps c for concurrent use. c must not be used directly afterwards
+func NewSync(c *Chip8) *SyncChip8 {
+	return &SyncChip8{c: c}
+}
+
+// Do calls fn with the write lock held
+func (s *SyncChip8) Do(fn func(c *Chip8)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.c)
+}
+
+// EmulateCycle executes one CPU instruction
+func (s *SyncChip8) EmulateCycle() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.EmulateCycle()
+}
+
+// RunFrame runs one 60Hz frame
+func (s *SyncChip8) RunFrame(cyclesPerFrame int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.RunFrame(cyclesPerFrame)
+}
+
+// TickTimers counts the delay and sound timers down by one
+func (s *SyncChip8) TickTimers() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.TickTimers()
+}
+
+// SetKey sets the state of a key
+func (s *SyncChip8) SetKey(key uint8, pressed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.SetKey(key, pressed)
+}
+
+// PressRune sets the state of the key mapped to r
+func (s *SyncChip8) PressRune(r rune, pressed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.PressRune(r, pressed)
+}
+
+// DrawFlag returns and resets the draw flag
+func (s *SyncChip8) DrawFlag() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.DrawFlag()
+}
+
+// GetDisplay returns the current display state at 64x32
+func (s *SyncChip8) GetDisplay() [ScreenWidth * ScreenHeight]uint8 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.GetDisplay()
+}
+
+// ActiveDisplay returns a copy of the display at the active resolution
+// together with that resolution, read under a single lock
+func (s *SyncChip8) ActiveDisplay() (display []uint8, width, height int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	width, height = s.c.GetDisplaySize()
+	return s.c.GetActiveDisplay(), width, height
+}
+
+// KeyState returns a copy of the pressed state of all 16 keys
+func (s *SyncChip8) KeyState() [16]bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.KeyState()
+}
+
+// Registers returns a copy of V0-VF
+func (s *SyncChip8) Registers() [RegisterCount]uint8 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.Registers()
+}
+
+// ProgramCounter returns the address of the next instruction
+func (s *SyncChip8) ProgramCounter() uint16 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.ProgramCounter()
+}
+
+// SoundActive reports whether the beep should currently be playing
+func (s *SyncChip8) SoundActive() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.c.SoundActive()
+}
@@ -0,0 +1,50 @@
+package chip8
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestSyncConcurrentAccess is meant for go test -race: it emulates on one
+// goroutine while others read the display and registers
+func TestSyncConcurrentAccess(t *testing.T) {
+	c := loadProgram(t,
+		0xA000, // LD I, 0 (font glyph 0)
+		0xD015, // DRW V0, V1, 5
+		0x7001, // ADD V0, 1
+		0x1202, // JP 0x202
+	)
+	s := NewSync(c)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for range 2000 {
+			if err := s.EmulateCycle(); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 2000 {
+			s.GetDisplay()
+			s.ActiveDisplay()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 2000 {
+			s.Registers()
+			s.DrawFlag()
+			s.SetKey(1, true)
+		}
+	}()
+	wg.Wait()
+
+	if pc := s.ProgramCounter(); pc < 0x202 || pc > 0x206 {
+		t.Errorf("ProgramCounter() = %03X, want inside the loop", pc)
+	}
+}
@@ -0,0 +1,37 @@
+package chip8
+
+// DefaultCycleCosts returns approximate COSMAC VIP machine-cycle costs for
+// each instruction class (see InstructionStats for the class names)
+// Drawing dominates because the VIP interpreter copies sprite data into
+// the display buffer a byte at a time
+func DefaultCycleCosts() map[string]int {
+	return map[string]int{
+		"system":     24,
+		"jump":       12,
+		"call":       26,
+		"skip":       14,
+		"load":       8,
+		"arithmetic": 44,
+		"random":     36,
+		"draw":       3812,
+		"input":      14,
+		"misc":       16,
+	}
+}
+
+// SetCycleCosts overrides the cost of the given instruction classes
+// Classes not present in costs keep their current cost
+func (c *Chip8) SetCycleCosts(costs map[string]int) {
+	for nibble, class := range opcodeClasses {
+		if cost, ok := costs[class]; ok {
+			c.cycleCosts[nibble] = cost
+		}
+	}
+}
+
+// LastCycleCost returns the cost of the most recently executed instruction
+// from the cycle cost table, letting a frontend pace execution to match
+// real hardware rather than a flat instructions-per-second rate
+func (c *Chip8) LastCycleCost() int {
+	return c.lastCost
+}
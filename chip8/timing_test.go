@@ -0,0 +1,28 @@
+package chip8
+
+import "testing"
+
+func TestCycleCosts(t *testing.T) {
+	c := loadProgram(t, 0x6001, 0xD015, 0x6002)
+	step(t, c, 1)
+	load := c.LastCycleCost()
+	step(t, c, 1)
+	draw := c.LastCycleCost()
+	if draw <= load {
+		t.Errorf("draw cost %d, want more than the register load's %d", draw, load)
+	}
+	if step := c.Step(); step.Cost != load {
+		t.Errorf("Step().Cost = %d, want %d", step.Cost, load)
+	}
+
+	c.SetCycleCosts(map[string]int{"load": 5000})
+	c.Reset()
+	step(t, c, 1)
+	if got := c.LastCycleCost(); got != 5000 {
+		t.Errorf("LastCycleCost() = %d after SetCycleCosts, want 5000", got)
+	}
+	step(t, c, 1)
+	if got := c.LastCycleCost(); got != draw {
+		t.Errorf("draw cost = %d, want %d left unchanged", got, draw)
+	}
+}